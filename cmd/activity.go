@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"zone-finder/types"
+	"zone-finder/workoutfile"
+)
+
+// Choose the activity zones are calculated from when a file holds several.
+// selector is a 1-based activity number or an activity Id, and is required
+// when there's more than one activity to choose from. The selected activity
+// is returned only for files holding several
+func selectActivity(workout workoutfile.WorkoutFile, selector string) (types.Activity, error) {
+	multi, ok := workout.(workoutfile.MultiActivityFile)
+	if !ok {
+		if selector != "" && selector != "1" {
+			return types.Activity{}, errors.New("workout file contains a single activity")
+		}
+		return types.Activity{}, nil
+	}
+
+	activities := multi.GetActivities()
+	if selector == "" {
+		if len(activities) > 1 {
+			return types.Activity{}, fmt.Errorf("workout file contains %d activities, choose one with --activity:\n%s",
+				len(activities), formatActivities(activities))
+		}
+		return types.Activity{}, nil
+	}
+
+	index, err := findActivity(activities, selector)
+	if err != nil {
+		return types.Activity{}, err
+	}
+
+	if err := multi.SelectActivity(index); err != nil {
+		return types.Activity{}, err
+	}
+
+	if len(activities) == 1 {
+		return types.Activity{}, nil
+	}
+
+	return activities[index], nil
+}
+
+func findActivity(activities []types.Activity, selector string) (int, error) {
+	if number, err := strconv.Atoi(selector); err == nil {
+		if number < 1 || number > len(activities) {
+			return 0, fmt.Errorf("activity %d out of range, choose one of:\n%s", number, formatActivities(activities))
+		}
+		return number - 1, nil
+	}
+
+	var matches []int
+	for i, activity := range activities {
+		if activity.Id == selector {
+			matches = append(matches, i)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return 0, fmt.Errorf("no activity with Id %s, choose one of:\n%s", selector, formatActivities(activities))
+	case 1:
+		return matches[0], nil
+	default:
+		return 0, fmt.Errorf("%d activities have Id %s, choose one by number:\n%s",
+			len(matches), selector, formatActivities(activities))
+	}
+}
+
+func formatActivities(activities []types.Activity) string {
+	lines := make([]string, len(activities))
+	for i, activity := range activities {
+		lines[i] = fmt.Sprintf("  %d: %s (%s)", i+1, activity.Id, activity.Sport)
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"zone-finder/filter"
+	"zone-finder/load"
+	"zone-finder/types"
+	"zone-finder/workoutfile"
+	"zone-finder/zones"
+)
+
+type analyzeOptions struct {
+	path     string
+	format   string
+	activity string
+	noFilter bool
+	lthr     int
+	// Banister TRIMP weighting, by sex
+	banister load.BanisterWeighting
+	scheme   string
+	schemes  []zones.Scheme
+	// sport of the --profile, empty without one
+	profileSport types.Sport
+	// zones are calculated from the known heart rates here, not the workout
+	settings zones.Options
+}
+
+// Parse flags for analyze followed by a single workout file path. Flags
+// override the values of a --profile
+func parseAnalyzeArgs(args []string) (analyzeOptions, error) {
+	var opts analyzeOptions
+
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&opts.format, "format", "", "")
+	flags.StringVar(&opts.activity, "activity", "", "")
+	flags.IntVar(&opts.lthr, "lthr", 0, "")
+	flags.IntVar(&opts.settings.MaxHR, "max-hr", 0, "")
+	flags.IntVar(&opts.settings.RestingHR, "resting-hr", 0, "")
+	method := flags.String("method", "", "")
+	flags.StringVar(&opts.scheme, "scheme", "", "")
+	schemesPath := flags.String("schemes", "", "")
+	profilePath := flags.String("profile", "", "")
+	averaging := flags.String("averaging", "", "")
+	flags.DurationVar(&opts.settings.Gaps.Threshold, "gap-threshold", zones.DefaultGapPolicy.Threshold, "")
+	flags.BoolVar(&opts.noFilter, "no-filter", false, "")
+	sex := flags.String("sex", "", "")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return analyzeOptions{}, err
+	}
+
+	switch *sex {
+	case "", "male":
+		opts.banister = load.BanisterMale
+	case "female":
+		opts.banister = load.BanisterFemale
+	default:
+		return analyzeOptions{}, fmt.Errorf("invalid sex %q: must be male or female", *sex)
+	}
+
+	if *profilePath != "" {
+		p, err := loadProfile(*profilePath)
+		if err != nil {
+			return analyzeOptions{}, err
+		}
+
+		if opts.lthr == 0 {
+			opts.lthr = p.LTHR
+		}
+		if opts.settings.MaxHR == 0 {
+			opts.settings.MaxHR = p.MaxHR
+		}
+		if opts.settings.RestingHR == 0 {
+			opts.settings.RestingHR = p.RestingHR
+		}
+		if *method == "" && opts.scheme == "" {
+			*method = p.Method
+			opts.scheme = p.Scheme
+		}
+		if *schemesPath == "" && opts.scheme == p.Scheme {
+			*schemesPath = p.Schemes
+		}
+		opts.profileSport = profileSport(p.Sport)
+	}
+
+	if *method != "" {
+		var err error
+		if opts.settings.Method, err = zones.ParseMethod(*method); err != nil {
+			return analyzeOptions{}, err
+		}
+	}
+
+	if *schemesPath != "" {
+		var err error
+		if opts.schemes, err = zones.LoadSchemes(*schemesPath); err != nil {
+			return analyzeOptions{}, err
+		}
+	}
+
+	if opts.scheme != "" {
+		scheme, err := lookupScheme(opts.scheme, opts.schemes, "")
+		if err != nil {
+			return analyzeOptions{}, err
+		}
+
+		if *method != "" && scheme.Anchor != opts.settings.Method {
+			return analyzeOptions{}, fmt.Errorf("scheme %q is based on %s, not %s", opts.scheme, scheme.Anchor, opts.settings.Method)
+		}
+		opts.settings.Method = scheme.Anchor
+	}
+
+	if opts.lthr < 0 || opts.settings.MaxHR < 0 || opts.settings.RestingHR < 0 {
+		return analyzeOptions{}, errors.New("invalid heart rate: --lthr, --max-hr and --resting-hr must be positive")
+	}
+
+	switch opts.settings.Method {
+	case zones.MethodLTHR:
+		if opts.lthr == 0 {
+			return analyzeOptions{}, errors.New("lthr zones need --lthr, or a --profile with one")
+		}
+	case zones.MethodMaxHR:
+		if opts.settings.MaxHR == 0 {
+			return analyzeOptions{}, errors.New("max-hr zones need --max-hr, or a --profile with one")
+		}
+	case zones.MethodHeartRateReserve:
+		if opts.settings.MaxHR == 0 || opts.settings.RestingHR == 0 {
+			return analyzeOptions{}, errors.New("hrr zones need --max-hr and --resting-hr, or a --profile with them")
+		}
+	}
+
+	if *averaging != "" {
+		var err error
+		if opts.settings.Averaging, err = zones.ParseAveraging(*averaging); err != nil {
+			return analyzeOptions{}, err
+		}
+	}
+
+	if opts.settings.Gaps.Threshold <= 0 {
+		return analyzeOptions{}, fmt.Errorf("invalid gap threshold %v: must be positive", opts.settings.Gaps.Threshold)
+	}
+
+	if err := validateArgs(append([]string{args[0]}, flags.Args()...)); err != nil {
+		return analyzeOptions{}, err
+	}
+
+	opts.path = flags.Arg(0)
+	return opts, nil
+}
+
+func showAnalyzeUsage(w io.Writer) {
+	usage := `
+Usage: zone-finder analyze [options] <file.ext>
+
+Report how a workout divides between zones you already know, without
+calculating LTHR from it: time in each zone, average heart rate, the zone
+of each lap, and training load (approximate hrTSS, Banister and Edwards
+TRIMP).
+
+Arguments:
+  <file.ext>    Path to a workout file, or - to read from stdin
+
+Options:
+  --lthr N      Lactate threshold heart rate the zones are based on
+  --method M    What zones are percentages of: lthr (default), max-hr or hrr
+  --max-hr N    Maximum heart rate, required for max-hr and hrr zones. When
+                given, it ends the top LTHR zone and gives Edwards TRIMP
+  --resting-hr N
+                Resting heart rate, required for hrr zones. With max HR,
+                it also gives Banister TRIMP for other zones
+  --scheme S    Zone scheme instead of the default five zones
+  --schemes F   JSON file of your own zone schemes
+  --profile F   Zones saved by zone-finder --save-profile; flags override
+                its values
+  --format      Workout format, detected when omitted
+  --activity N  Activity to analyze, for files holding several
+  --averaging A How heart rates are averaged: sample (default) or time
+  --sex S       male (default) or female, weighting Banister TRIMP
+  --gap-threshold D
+                Time between samples that counts as a gap (default 30s)
+  --no-filter   Keep heart rate artifacts, which are removed by default
+  -h, --help    Show this help message
+
+Examples:
+  zone-finder analyze --lthr 172 run.fit
+  zone-finder analyze --lthr 160 --scheme friel ride.fit
+  zone-finder analyze --method hrr --max-hr 192 --resting-hr 48 run.fit
+  zone-finder analyze --profile athlete.json run.fit
+`
+
+	fmt.Fprint(w, usage)
+}
+
+func runAnalyze(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if isHelp := checkHelpFlag(args); isHelp {
+		showAnalyzeUsage(stdout)
+		return exitOK
+	}
+
+	opts, err := parseAnalyzeArgs(args)
+	if errors.Is(err, flag.ErrHelp) {
+		showAnalyzeUsage(stdout)
+		return exitOK
+	} else if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		showAnalyzeUsage(stderr)
+		return exitError
+	}
+
+	workout, detection, err := parseWorkout(opts.path, opts.format, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to parse workout file: %v\n", err)
+		if errors.Is(err, workoutfile.ErrFormatNotDetected) {
+			fmt.Fprintf(stderr, "hint: pass --format with one of %s\n", strings.Join(workoutfile.Formats(), ", "))
+		}
+		return exitError
+	}
+
+	activity, err := selectActivity(workout, opts.activity)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to select activity: %v\n", err)
+		return exitError
+	}
+
+	hrData, err := workout.GetHRDataPoints()
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to process heart rate data: %v\n", err)
+		return exitError
+	}
+
+	var filterResults []filter.Result
+	if !opts.noFilter {
+		hrData, filterResults = filter.Default().Apply(hrData)
+	}
+
+	if len(hrData) == 0 {
+		fmt.Fprintf(stderr, "failed to analyze workout: %v\n", zones.ErrNoHRData)
+		return exitNoHRData
+	}
+
+	opts.settings.Sport = workoutfile.Sport(workout)
+	if sport := profileSport(opts.settings.Sport); opts.profileSport != "" && sport != "" && opts.profileSport != sport {
+		fmt.Fprintf(stderr, "the profile is for %s, but the workout is %s; use a profile saved while %s\n",
+			opts.profileSport, sport, sport)
+		return exitError
+	}
+	if opts.scheme != "" {
+		scheme, err := lookupScheme(opts.scheme, opts.schemes, opts.settings.Sport)
+		if err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			return exitError
+		}
+		opts.settings.Scheme = &scheme
+	}
+
+	result, err := knownZones(hrData, opts.lthr, opts.settings)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to calculate zones: %v\n", err)
+		return exitError
+	}
+	fmt.Fprint(stderr, formatAboveMaxHR(result))
+
+	laps, err := workoutfile.Laps(workout)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to read laps: %v\n", err)
+		return exitError
+	}
+
+	avgHR, _ := zones.AverageHeartRate(hrData, opts.settings.Averaging)
+
+	fmt.Fprintf(stdout, "Format: %s\n", detection)
+	if activity.Id != "" {
+		fmt.Fprintf(stdout, "Activity: %s\n", activity.Id)
+	}
+	if opts.settings.Averaging != zones.AveragingSampleMean {
+		fmt.Fprintf(stdout, "Averaging: %s\n", opts.settings.Averaging)
+	}
+	if !opts.noFilter {
+		fmt.Fprintf(stdout, "Filtered: %s\n", formatFilterResults(filterResults))
+	}
+	fmt.Fprint(stdout, formatGaps(hrData, zones.FindGaps(hrData, opts.settings.Gaps.Threshold), opts.settings.Gaps.Threshold))
+	fmt.Fprint(stdout, formatOutput(result))
+	fmt.Fprintf(stdout, "Average HR: %.0f bpm (%s)\n", avgHR, formatZoneOf(result, avgHR))
+	fmt.Fprint(stdout, formatLoad(load.Calculate(hrData, result, load.Options{
+		Gaps:      opts.settings.Gaps,
+		Banister:  opts.banister,
+		RestingHR: opts.settings.RestingHR,
+		MaxHR:     opts.settings.MaxHR,
+	})))
+	fmt.Fprint(stdout, formatTimeInZones(zones.CalculateTimeInZones(hrData, result, opts.settings.Gaps)))
+	fmt.Fprint(stdout, formatLaps(laps, hrData, result, opts.settings.Averaging))
+	return exitOK
+}
+
+// Zones from known heart rates rather than ones found from the workout
+func knownZones(hrData []types.HRDataPoint, lthr int, settings zones.Options) (zones.HeartRateZones, error) {
+	if settings.Method != zones.MethodLTHR {
+		// the max, and resting HR for hrr, are given so the workout isn't
+		// searched for them
+		return zones.CalculateZonesFromHRDataWithOptions(hrData, settings)
+	}
+
+	return zones.CapZones(zones.CalculateZonesWithOptions(lthr, settings), hrData, settings)
+}
+
+// e.g. "zone 3", or "below zones"
+func formatZoneOf(result zones.HeartRateZones, heartRate float64) string {
+	zone, ok := result.ZoneFor(int(heartRate + 0.5))
+	if !ok {
+		return "below zones"
+	}
+
+	return "zone " + zone.Label()
+}
+
+// Tabulate each lap's duration, average heart rate and zone, or nothing
+// when the workout has no laps
+func formatLaps(laps []types.Lap, hrData []types.HRDataPoint, result zones.HeartRateZones, averaging zones.Averaging) string {
+	if len(laps) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Laps:\n")
+	for i, lap := range laps {
+		avgHR, err := zones.AverageHeartRate(lapHRData(hrData, lap), averaging)
+		if err != nil {
+			// filtered out, or not recorded as samples
+			avgHR = float64(lap.AvgHeartRate)
+		}
+
+		if avgHR == 0 {
+			fmt.Fprintf(&b, "  Lap %-2d %7s  no heart rate\n", i+1, lap.TotalTime.Round(time.Second))
+			continue
+		}
+
+		fmt.Fprintf(&b, "  Lap %-2d %7s  avg %3.0f bpm  %s\n", i+1, lap.TotalTime.Round(time.Second), avgHR, formatZoneOf(result, avgHR))
+	}
+
+	return b.String()
+}
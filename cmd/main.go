@@ -2,42 +2,309 @@ package main
 
 import (
 	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"math"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
+	"zone-finder/filter"
+	"zone-finder/load"
+	"zone-finder/types"
 	"zone-finder/workoutfile"
 	"zone-finder/zones"
 )
 
-func formatOutput(zones zones.HeartRateZones) string {
-	const output = `
-LTHR: %v bpm
-Zone 1: 0-%v
-Zone 2: %v-%v
-Zone 3: %v-%v
-Zone 4: %v-%v
-Zone 5: %v+
-`
-	return strings.TrimPrefix(fmt.Sprintf(
-		output,
-		zones.LTHR,
-		zones.Zones[0].Max,
-		zones.Zones[1].Min,
-		zones.Zones[1].Max,
-		zones.Zones[2].Min,
-		zones.Zones[2].Max,
-		zones.Zones[3].Min,
-		zones.Zones[3].Max,
-		zones.Zones[4].Min,
-	), "\n")
+func formatOutput(result zones.HeartRateZones) string {
+	var b strings.Builder
+
+	if result.Sport != "" {
+		fmt.Fprintf(&b, "Sport: %s\n", result.Sport)
+	}
+	if result.Scheme != "" {
+		fmt.Fprintf(&b, "Scheme: %s\n", result.Scheme)
+	}
+	if result.Window != nil {
+		b.WriteString(formatWindow(*result.Window))
+	}
+
+	switch result.Method {
+	case zones.MethodHeartRateReserve:
+		b.WriteString(formatMaxHR(result))
+		b.WriteString(formatRestingHR(result))
+	case zones.MethodMaxHR:
+		b.WriteString(formatMaxHR(result))
+	default:
+		fmt.Fprintf(&b, "LTHR: %v bpm\n", result.LTHR)
+		if result.MaxHR != 0 {
+			b.WriteString(formatMaxHR(result))
+		}
+	}
+
+	for _, zone := range result.Zones {
+		if zone.Max == 0 {
+			fmt.Fprintf(&b, "Zone %s: %v+\n", zone.Label(), zone.Min)
+		} else {
+			fmt.Fprintf(&b, "Zone %s: %v-%v\n", zone.Label(), zone.Min, zone.Max)
+		}
+	}
+
+	return b.String()
+}
+
+var maxHRSourceDescriptions = map[zones.MaxHRSource]string{
+	zones.MaxHRObserved: "observed peak",
+	zones.MaxHRGiven:    "given",
+	zones.MaxHRTanaka:   "Tanaka formula, 208 - 0.7 x age",
+	zones.MaxHRGulati:   "Gulati formula, 206 - 0.88 x age",
+	zones.MaxHRFox:      "Fox formula, 220 - age",
+}
+
+func formatMaxHR(result zones.HeartRateZones) string {
+	if description, ok := maxHRSourceDescriptions[result.MaxHRSource]; ok {
+		return fmt.Sprintf("Max HR: %v bpm (%s)\n", result.MaxHR, description)
+	}
+
+	return fmt.Sprintf("Max HR: %v bpm\n", result.MaxHR)
+}
+
+var restingHRSourceDescriptions = map[zones.RestingHRSource]string{
+	zones.RestingHREstimated: "estimated from workout",
+	zones.RestingHRGiven:     "given",
+}
+
+func formatRestingHR(result zones.HeartRateZones) string {
+	if description, ok := restingHRSourceDescriptions[result.RestingHRSource]; ok {
+		return fmt.Sprintf("Resting HR: %v bpm (%s)\n", result.RestingHR, description)
+	}
+
+	return fmt.Sprintf("Resting HR: %v bpm\n", result.RestingHR)
+}
+
+// Fraction of max HR above which an estimated resting HR is more likely the
+// workout's easiest effort than the athlete's resting heart rate
+const implausibleRestingHR = 0.6
+
+// Warn when resting HR was estimated from a workout that never came close to
+// resting, e.g. "warning: estimated resting HR 154 bpm is 87% of max HR ..."
+func formatRestingHRWarning(result zones.HeartRateZones) string {
+	if result.RestingHRSource != zones.RestingHREstimated || result.MaxHR == 0 {
+		return ""
+	}
+
+	fraction := float64(result.RestingHR) / float64(result.MaxHR)
+	if fraction <= implausibleRestingHR {
+		return ""
+	}
+
+	return fmt.Sprintf("warning: estimated resting HR %d bpm is %.0f%% of max HR %d bpm, far above a typical resting heart rate; give yours with --resting-hr\n",
+		result.RestingHR, fraction*100, result.MaxHR)
+}
+
+// Describe where in the workout LTHR was calculated from, so athletes can
+// check the right effort was picked
+func formatWindow(window zones.Window) string {
+	return fmt.Sprintf("Effort: %s-%s into the workout (%s-%s)\nEffort HR: avg %.1f, min %d, max %d over %d samples\n",
+		window.Offset.Round(time.Second),
+		(window.Offset + window.Duration()).Round(time.Second),
+		window.Start.Format("15:04:05"),
+		window.End.Format("15:04:05 MST"),
+		window.AvgHR,
+		window.MinHR,
+		window.MaxHR,
+		window.Samples,
+	)
 }
 
 func main() {
-	exitCode := run(os.Args, os.Stdout, os.Stderr)
+	exitCode := run(os.Args, os.Stdin, os.Stdout, os.Stderr)
 	os.Exit(exitCode)
 }
 
+// Exit codes, so scripts can tell why zones couldn't be calculated
+const (
+	exitOK            = 0
+	exitError         = 1
+	exitNoHRData      = 2
+	exitTooShort      = 3
+	exitNoValidWindow = 4
+)
+
+type options struct {
+	path     string
+	format   string
+	lap      int
+	activity string
+	noFilter bool
+	// resolved once the workout's sport is known
+	scheme string
+	// user-defined schemes, searched before the built-in ones
+	schemes     []zones.Scheme
+	schemesPath string
+	// where to save the zones found, for analyze --profile
+	profilePath string
+	// how zones are calculated, and LTHR found when not using a lap
+	settings zones.Options
+}
+
+// Parse flags followed by a single workout file path
+func parseArgs(args []string) (options, error) {
+	var opts options
+
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&opts.format, "format", "", "")
+	flags.IntVar(&opts.lap, "lap", 0, "")
+	flags.StringVar(&opts.activity, "activity", "", "")
+	protocol := flags.String("protocol", "", "")
+	averaging := flags.String("averaging", "", "")
+	method := flags.String("method", "", "")
+	flags.StringVar(&opts.scheme, "scheme", "", "")
+	flags.StringVar(&opts.schemesPath, "schemes", "", "")
+	flags.StringVar(&opts.profilePath, "save-profile", "", "")
+	flags.IntVar(&opts.settings.MaxHR, "max-hr", 0, "")
+	flags.IntVar(&opts.settings.RestingHR, "resting-hr", 0, "")
+	maxHRSource := flags.String("max-hr-source", "", "")
+	flags.IntVar(&opts.settings.Age, "age", 0, "")
+	flags.DurationVar(&opts.settings.Window, "window", zones.DefaultOptions().Window, "")
+	flags.DurationVar(&opts.settings.Gaps.Threshold, "gap-threshold", zones.DefaultGapPolicy.Threshold, "")
+	flags.BoolVar(&opts.noFilter, "no-filter", false, "")
+	minCoverage := flags.Float64("min-coverage", zones.DefaultGapPolicy.MinCoverage*100, "")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return options{}, err
+	}
+
+	if *protocol != "" {
+		if opts.lap > 0 {
+			return options{}, errors.New("--protocol and --lap can't be combined")
+		}
+
+		var err error
+		if opts.settings.Protocol, err = zones.ParseProtocol(*protocol); err != nil {
+			return options{}, err
+		}
+	}
+
+	// there's no flag for the tolerance, so the default bounds the window
+	if minWindow := zones.DefaultOptions().Tolerance; opts.settings.Window <= minWindow {
+		return options{}, fmt.Errorf("invalid --window %v: must be longer than %v", opts.settings.Window, minWindow)
+	}
+
+	if opts.settings.Window != zones.DefaultOptions().Window {
+		if opts.lap > 0 {
+			return options{}, errors.New("--window and --lap can't be combined")
+		}
+		if opts.settings.Protocol == zones.ProtocolFriel {
+			return options{}, errors.New("--window can't be combined with --protocol friel, which uses a 30-minute time trial")
+		}
+	}
+
+	if *method != "" {
+		var err error
+		if opts.settings.Method, err = zones.ParseMethod(*method); err != nil {
+			return options{}, err
+		}
+	}
+
+	if opts.schemesPath != "" {
+		if opts.scheme == "" {
+			return options{}, errors.New("--schemes requires --scheme to select one of them")
+		}
+
+		var err error
+		if opts.schemes, err = zones.LoadSchemes(opts.schemesPath); err != nil {
+			return options{}, err
+		}
+	}
+
+	if opts.scheme != "" {
+		if *method != "" {
+			return options{}, errors.New("--scheme can't be combined with --method: the scheme sets what its zones are percentages of")
+		}
+
+		// the sport only picks between variants with the same anchor
+		scheme, err := lookupScheme(opts.scheme, opts.schemes, "")
+		if err != nil {
+			return options{}, err
+		}
+		opts.settings.Method = scheme.Anchor
+	}
+
+	if opts.settings.Method != zones.MethodLTHR {
+		if *protocol != "" || opts.lap > 0 || opts.settings.Window != zones.DefaultOptions().Window {
+			return options{}, fmt.Errorf("%s zones can't be combined with --protocol, --lap or --window", opts.settings.Method)
+		}
+	}
+
+	if *maxHRSource != "" {
+		if opts.settings.MaxHR != 0 {
+			return options{}, errors.New("--max-hr and --max-hr-source can't be combined")
+		}
+
+		var err error
+		if opts.settings.MaxHRSource, err = zones.ParseMaxHRSource(*maxHRSource); err != nil {
+			return options{}, err
+		}
+		if opts.settings.MaxHRSource == zones.MaxHRGiven || opts.settings.MaxHRSource == zones.MaxHRUnknown {
+			return options{}, fmt.Errorf("invalid max HR source %q: give --max-hr instead, or one of observed, tanaka, gulati or fox", *maxHRSource)
+		}
+	}
+
+	if opts.settings.Age != 0 && (opts.settings.Age < zones.MinAge || opts.settings.Age > zones.MaxAge) {
+		return options{}, fmt.Errorf("invalid age %d: must be between %d and %d", opts.settings.Age, zones.MinAge, zones.MaxAge)
+	}
+
+	if opts.settings.MaxHRSource.IsFormula() != (opts.settings.Age != 0) {
+		return options{}, errors.New("--age is required by, and only used by, --max-hr-source tanaka, gulati or fox")
+	}
+
+	if opts.settings.Method != zones.MethodHeartRateReserve && opts.settings.RestingHR != 0 {
+		return options{}, errors.New("--resting-hr requires --method hrr, or a scheme anchored to it")
+	}
+
+	if opts.settings.MaxHR < 0 {
+		return options{}, fmt.Errorf("invalid max HR %d: must be positive", opts.settings.MaxHR)
+	}
+
+	if opts.settings.RestingHR < 0 {
+		return options{}, fmt.Errorf("invalid resting HR %d: must be positive", opts.settings.RestingHR)
+	}
+
+	if *averaging != "" {
+		var err error
+		if opts.settings.Averaging, err = zones.ParseAveraging(*averaging); err != nil {
+			return options{}, err
+		}
+	}
+
+	if opts.settings.Gaps.Threshold <= 0 {
+		return options{}, fmt.Errorf("invalid gap threshold %v: must be positive", opts.settings.Gaps.Threshold)
+	}
+
+	if *minCoverage < 0 || *minCoverage > 100 {
+		return options{}, fmt.Errorf("invalid minimum coverage %v%%: must be between 0 and 100", *minCoverage)
+	}
+	opts.settings.Gaps.MinCoverage = *minCoverage / 100
+	if opts.settings.Gaps.MinCoverage == 0 {
+		// zero would be taken as the default
+		opts.settings.Gaps.MinCoverage = -1
+	}
+
+	if err := validateArgs(append([]string{args[0]}, flags.Args()...)); err != nil {
+		return options{}, err
+	}
+
+	if opts.lap < 0 {
+		return options{}, fmt.Errorf("invalid lap %d: laps are numbered from 1", opts.lap)
+	}
+
+	opts.path = flags.Arg(0)
+	return opts, nil
+}
+
 func validateArgs(args []string) error {
 	if len(args) == 1 {
 		return errors.New("missing required argument: file path")
@@ -50,26 +317,106 @@ func validateArgs(args []string) error {
 
 func showUsage(w io.Writer) {
 	usage := `
-Usage: zone-finder <file.ext>
+Usage: zone-finder [options] <file.ext>
+       zone-finder analyze [options] <file.ext>
 
-Calculate heart rate training zones from FIT or TCX workout files using the
-Lactate Threshold Heart Rate (LTHR) method.
+Calculate heart rate training zones from FIT, TCX or GPX workout files using
+the Lactate Threshold Heart Rate (LTHR) method. Use analyze to apply zones
+you already know to any workout; see zone-finder analyze --help.
 
 Arguments:
-  <file.ext>    Path to a workout file
+  <file.ext>    Path to a workout file, or - to read from stdin
 
 Options:
+  --format      Workout format, detected from the file contents or
+                extension when omitted
+  --lap N       Use the average heart rate of lap N as LTHR instead of
+                the best 20-minute window
+  --activity N  Activity to analyze, by number or Id, for files holding
+                several activities (multisport TCX)
+  --protocol P  LTHR field test protocol:
+                  best-window  average of the best 20 minutes (default)
+                  friel        average of the last 20 minutes of a
+                               30-minute time trial
+                  95-percent   95%% of the average of a 20-minute effort
+  --window D    Duration of the threshold effort for best-window and
+                95-percent, e.g. 8m or 30m (default 20m)
+  --method M    What zones are percentages of:
+                  lthr         lactate threshold heart rate (default)
+                  max-hr       maximum heart rate, 50-60-70-80-90%%
+                  hrr          heart rate reserve (Karvonen), the same
+                               percentages of max minus resting HR
+  --scheme S    Zone scheme instead of the default five zones, one of
+                those from --schemes or built in:
+                  friel        Joe Friel's seven LTHR zones (1-4, 5a-5c),
+                               for running or cycling by the workout's
+                               sport
+                  friel-run    Friel's running zones
+                  friel-bike   Friel's cycling zones
+  --schemes F   JSON file of your own zone schemes, each with percentages
+                of LTHR, max HR or heart rate reserve
+  --max-hr N    Maximum heart rate, ending the top zone. LTHR zones are
+                open-ended, and max-hr and hrr zones use the workout's
+                peak, unless it or --max-hr-source is given
+  --max-hr-source S
+                Where max heart rate comes from instead of --max-hr:
+                  observed     the workout's peak, held for 5 seconds
+                  tanaka       208 - 0.7 x age
+                  gulati       206 - 0.88 x age, derived from women
+                  fox          220 - age
+  --age N       Age in years, for the tanaka, gulati and fox formulas
+  --resting-hr N
+                Resting heart rate for hrr zones, estimated from the
+                workout's lowest sustained heart rate when omitted
+  --averaging A How heart rates are averaged:
+                  sample       every sample counts equally (default)
+                  time         samples are weighted by the time they
+                               cover, for smart recording devices
+  --gap-threshold D
+                Time between samples that counts as a gap in heart rate
+                data, e.g. a strap dropout (default 30s)
+  --min-coverage P
+                Refuse windows with less than P percent of their time
+                covered by heart rate data (default 90)
+  --no-filter   Keep heart rate artifacts such as spikes and cadence lock,
+                which are removed by default
+  --save-profile F
+                Save the zones found to F, for zone-finder analyze --profile
   -h, --help    Show this help message
 
+Exit codes:
+  0  success
+  1  invalid arguments or unreadable workout
+  2  no heart rate data
+  3  workout shorter than the window
+  4  no window with enough heart rate coverage
+
+Supported formats: %s
+
 Examples:
   zone-finder workout.tcx
   zone-finder ~/Documents/garmin-run.fit
+  zone-finder strava-export.gpx
+  cat workout.fit | zone-finder -
+  zone-finder --lap 3 threshold-test.fit
+  zone-finder --protocol friel time-trial.fit
+  zone-finder --window 8m field-test.fit
+  zone-finder --averaging time smart-recording.fit
+  zone-finder --scheme friel threshold-ride.fit
+  zone-finder --schemes my-zones.json --scheme coach easy-run.fit
+  zone-finder --method max-hr --max-hr 192 easy-run.fit
+  zone-finder --method hrr --resting-hr 48 --max-hr 192 easy-run.fit
+  zone-finder --max-hr-source tanaka --age 42 threshold-run.fit
+  zone-finder --save-profile athlete.json threshold-test.fit
 
-The program analyzes the last 20 minutes of your workout to determine
-your LTHR, then calculates 5 training zones based on percentages of LTHR.
+By default the program finds the 20 minutes of your workout with the
+highest average heart rate to determine your LTHR, then calculates 5
+training zones from it. --protocol, --method and --scheme change how LTHR
+is found and what the zones are based on; analyze applies zones you already
+know.
 `
 
-	fmt.Fprint(w, usage)
+	fmt.Fprintf(w, usage, strings.Join(workoutfile.Formats(), ", "))
 }
 
 func checkHelpFlag(args []string) bool {
@@ -85,36 +432,343 @@ func checkHelpFlag(args []string) bool {
 	return false
 }
 
-func run(args []string, stdout io.Writer, stderr io.Writer) int {
+// Find a scheme by name among user-defined schemes, then the built-in ones
+func lookupScheme(name string, schemes []zones.Scheme, sport types.Sport) (zones.Scheme, error) {
+	for _, scheme := range schemes {
+		if scheme.Name == name {
+			return scheme, nil
+		}
+	}
+
+	return zones.LookupScheme(name, sport)
+}
+
+func parseWorkout(path, format string, stdin io.Reader) (workoutfile.WorkoutFile, workoutfile.Detection, error) {
+	var r io.Reader = stdin
+	if path != "-" {
+		workoutFile, err := os.Open(path)
+		if err != nil {
+			return nil, workoutfile.Detection{}, err
+		}
+		defer workoutFile.Close()
+
+		r = workoutFile
+	}
+
+	workout, detection, err := workoutfile.ParseWithFormat(r, path, format)
+	if detection.Detector == workoutfile.DetectedByCaller {
+		detection.Detector = "--format"
+	}
+
+	return workout, detection, err
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) > 1 && args[1] == "analyze" {
+		return runAnalyze(args[1:], stdin, stdout, stderr)
+	}
+
 	if isHelp := checkHelpFlag(args); isHelp {
 		showUsage(stdout)
-		return 0
+		return exitOK
 	}
 
-	if err := validateArgs(args); err != nil {
+	opts, err := parseArgs(args)
+	if errors.Is(err, flag.ErrHelp) {
+		showUsage(stdout)
+		return exitOK
+	} else if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
 		showUsage(stderr)
-		return 1
+		return exitError
 	}
 
-	workoutFile := args[1]
-	workout, err := workoutfile.ParseFile(workoutFile)
+	workout, detection, err := parseWorkout(opts.path, opts.format, stdin)
 	if err != nil {
 		fmt.Fprintf(stderr, "failed to parse workout file: %v\n", err)
-		return 1
+		if errors.Is(err, workoutfile.ErrFormatNotDetected) {
+			fmt.Fprintf(stderr, "hint: pass --format with one of %s\n", strings.Join(workoutfile.Formats(), ", "))
+		}
+		return exitError
+	}
+
+	activity, err := selectActivity(workout, opts.activity)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to select activity: %v\n", err)
+		return exitError
 	}
 
 	hrData, err := workout.GetHRDataPoints()
 	if err != nil {
 		fmt.Fprintf(stderr, "failed to process heart rate data: %v\n", err)
-		return 1
+		return exitError
 	}
 
-	zones, err := zones.CalculateZonesFromHRData(hrData)
+	var filterResults []filter.Result
+	if !opts.noFilter {
+		hrData, filterResults = filter.Default().Apply(hrData)
+	}
+
+	opts.settings.Sport = workoutfile.Sport(workout)
+	if opts.scheme != "" {
+		scheme, err := lookupScheme(opts.scheme, opts.schemes, opts.settings.Sport)
+		if err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			return exitError
+		}
+		opts.settings.Scheme = &scheme
+	}
+
+	var result zones.HeartRateZones
+	var lap types.Lap
+	if opts.lap > 0 {
+		var lthr int
+		var window zones.Window
+		if lthr, lap, window, err = findLapLTHR(workout, hrData, opts.lap, opts.settings.Averaging); err == nil {
+			if result, err = zones.CapZones(zones.CalculateZonesWithOptions(lthr, opts.settings), hrData, opts.settings); err == nil {
+				result.Window = &window
+			}
+		}
+	} else {
+		result, err = zones.CalculateZonesFromHRDataWithOptions(hrData, opts.settings)
+	}
 	if err != nil {
 		fmt.Fprintf(stderr, "failed to calculate zones: %v\n", err)
-		return 1
+		if hint := errorHint(err, hrData, opts.settings.Protocol, opts.settings.Gaps.Threshold); hint != "" {
+			fmt.Fprintf(stderr, "hint: %s\n", hint)
+		}
+		return exitCodeFor(err)
+	}
+	fmt.Fprint(stderr, formatAboveMaxHR(result))
+	fmt.Fprint(stderr, formatRestingHRWarning(result))
+
+	fmt.Fprintf(stdout, "Format: %s\n", detection)
+	if activity.Id != "" {
+		fmt.Fprintf(stdout, "Activity: %s\n", activity.Id)
+	}
+	switch {
+	case opts.settings.Method != zones.MethodLTHR:
+		fmt.Fprintf(stdout, "Method: %s\n", opts.settings.Method)
+	case opts.lap > 0:
+		fmt.Fprintf(stdout, "Lap: %d (%s)\n", opts.lap, lap.TotalTime.Round(time.Second))
+	default:
+		fmt.Fprintf(stdout, "Protocol: %s\n", opts.settings.Protocol)
+		if opts.settings.Window != zones.DefaultOptions().Window {
+			fmt.Fprintf(stdout, "Window: %s\n", opts.settings.Window)
+		}
+	}
+	if opts.settings.Averaging != zones.AveragingSampleMean {
+		fmt.Fprintf(stdout, "Averaging: %s\n", opts.settings.Averaging)
+	}
+	if !opts.noFilter {
+		fmt.Fprintf(stdout, "Filtered: %s\n", formatFilterResults(filterResults))
+	}
+	fmt.Fprint(stdout, formatGaps(hrData, zones.FindGaps(hrData, opts.settings.Gaps.Threshold), opts.settings.Gaps.Threshold))
+	fmt.Fprint(stdout, formatOutput(result))
+	fmt.Fprint(stdout, formatLoad(load.Calculate(hrData, result, load.Options{Gaps: opts.settings.Gaps})))
+	fmt.Fprint(stdout, formatTimeInZones(zones.CalculateTimeInZones(hrData, result, opts.settings.Gaps)))
+
+	if opts.profilePath != "" {
+		schemesPath := opts.schemesPath
+		if schemesPath != "" {
+			// so the profile can be used from any directory
+			if schemesPath, err = filepath.Abs(schemesPath); err != nil {
+				fmt.Fprintf(stderr, "failed to save profile: %v\n", err)
+				return exitError
+			}
+		}
+
+		if err := saveProfile(opts.profilePath, profileFor(result, schemesPath)); err != nil {
+			fmt.Fprintf(stderr, "failed to save profile: %v\n", err)
+			return exitError
+		}
+		fmt.Fprintf(stdout, "Profile saved to %s\n", opts.profilePath)
+	}
+
+	return exitOK
+}
+
+func exitCodeFor(err error) int {
+	var tooShort *zones.TooShortError
+
+	switch {
+	case errors.Is(err, zones.ErrNoHRData):
+		return exitNoHRData
+	case errors.As(err, &tooShort):
+		return exitTooShort
+	case errors.Is(err, zones.ErrNoValidWindow):
+		return exitNoValidWindow
+	default:
+		return exitError
+	}
+}
+
+// Suggest how to get zones from a workout they couldn't be calculated for,
+// or "" when there's nothing to suggest
+func errorHint(err error, hrData []types.HRDataPoint, protocol zones.Protocol, gapThreshold time.Duration) string {
+	var tooShort *zones.TooShortError
+	var coverage *zones.CoverageError
+
+	switch {
+	case errors.Is(err, zones.ErrNoHRData):
+		return "the workout has no heart rate data, was a heart rate monitor connected?"
+	case errors.As(err, &tooShort) && protocol == zones.ProtocolFriel:
+		// the time trial's length is fixed, so --window can't help
+		return fmt.Sprintf("your workout was %s, but the friel protocol needs a %s time trial; try --protocol best-window, or a longer workout",
+			tooShort.Have.Round(time.Second), tooShort.Need)
+	case errors.As(err, &tooShort):
+		return fmt.Sprintf("your workout was %s, but the window needs %s; try a shorter --window",
+			tooShort.Have.Round(time.Second), tooShort.Need)
+	case errors.As(err, &coverage):
+		gaps := zones.FindGaps(hrData, gapThreshold)
+		return fmt.Sprintf("the heart rate data has %d gaps longer than %s; lower --min-coverage to accept windows with gaps",
+			len(gaps), gapThreshold)
+	default:
+		return ""
+	}
+}
+
+// Warn about each zone left out because it starts above max HR, e.g.
+// "warning: zone 5c starts at 184 bpm, above the max HR of 178 bpm (observed); it was left out"
+func formatAboveMaxHR(result zones.HeartRateZones) string {
+	var b strings.Builder
+	for _, zone := range result.AboveMaxHR {
+		fmt.Fprintf(&b, "warning: zone %s starts at %d bpm, above the max HR of %d bpm (%s); it was left out\n",
+			zone.Label(), zone.Min, result.MaxHR, result.MaxHRSource)
+	}
+
+	return b.String()
+}
+
+// e.g. "6 samples (range 1, rate 5, hampel 0)"
+func formatFilterResults(results []filter.Result) string {
+	stages := make([]string, len(results))
+	for i, result := range results {
+		stages[i] = result.String()
+	}
+
+	return fmt.Sprintf("%d samples (%s)", filter.Removed(results), strings.Join(stages, ", "))
+}
+
+// e.g. "Load: approx. hrTSS 82.4, Edwards TRIMP 151.2", leaving out metrics
+// that weren't calculated, or nothing when none were
+func formatLoad(l load.Load) string {
+	var metrics []string
+	if l.HRTSS != 0 {
+		metrics = append(metrics, fmt.Sprintf("approx. hrTSS %.1f", l.HRTSS))
+	}
+	if l.BanisterTRIMP != 0 {
+		metrics = append(metrics, fmt.Sprintf("Banister TRIMP %.1f", l.BanisterTRIMP))
+	}
+	if l.EdwardsTRIMP != 0 {
+		metrics = append(metrics, fmt.Sprintf("Edwards TRIMP %.1f", l.EdwardsTRIMP))
+	}
+	if len(metrics) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("Load: %s\n", strings.Join(metrics, ", "))
+}
+
+// Tabulate the time spent in each zone, e.g.
+//
+//	Time in zones:
+//	  Zone 1  12m30s  20.8%
+//	  Zone 2   30m0s  50.0%
+func formatTimeInZones(distribution zones.TimeInZones) string {
+	type row struct {
+		label    string
+		duration time.Duration
+	}
+
+	var rows []row
+	if distribution.Below > 0 {
+		rows = append(rows, row{"Below zones", distribution.Below})
+	}
+	for _, zoneTime := range distribution.Zones {
+		rows = append(rows, row{"Zone " + zoneTime.Zone.Label(), zoneTime.Duration})
+	}
+
+	labelWidth, durationWidth := 0, 0
+	for _, r := range rows {
+		labelWidth = max(labelWidth, len(r.label))
+		durationWidth = max(durationWidth, len(r.duration.Round(time.Second).String()))
+	}
+
+	var b strings.Builder
+	b.WriteString("Time in zones:\n")
+	for _, r := range rows {
+		fmt.Fprintf(&b, "  %-*s  %*s  %5.1f%%\n", labelWidth, r.label, durationWidth, r.duration.Round(time.Second), distribution.Percent(r.duration))
+	}
+
+	return b.String()
+}
+
+// List gaps in heart rate data relative to the start of the workout, or
+// nothing when there are none
+func formatGaps(hrData []types.HRDataPoint, gaps []zones.Gap, threshold time.Duration) string {
+	if len(gaps) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Gaps: %d longer than %s\n", len(gaps), threshold)
+
+	workoutStart := hrData[0].Timestamp
+	for _, gap := range gaps {
+		fmt.Fprintf(&b, "  %s-%s (%s)\n",
+			gap.Start.Sub(workoutStart).Round(time.Second),
+			gap.End.Sub(workoutStart).Round(time.Second),
+			gap.Duration().Round(time.Second),
+		)
+	}
+
+	return b.String()
+}
+
+// Use the average heart rate over a whole lap as LTHR, for structured tests
+// where the threshold effort is recorded as its own lap
+func findLapLTHR(workout workoutfile.WorkoutFile, hrData []types.HRDataPoint, lapNumber int, averaging zones.Averaging) (int, types.Lap, zones.Window, error) {
+	laps, err := workoutfile.Laps(workout)
+	if err != nil {
+		return 0, types.Lap{}, zones.Window{}, err
+	}
+
+	if lapNumber > len(laps) {
+		return 0, types.Lap{}, zones.Window{}, fmt.Errorf("lap %d requested but the workout has %d laps", lapNumber, len(laps))
+	}
+
+	if len(hrData) == 0 {
+		return 0, types.Lap{}, zones.Window{}, zones.ErrNoHRData
+	}
+
+	lap := laps[lapNumber-1]
+
+	lapData := lapHRData(hrData, lap)
+	workoutStart := hrData[0].Timestamp
+	for _, dp := range hrData {
+		if dp.Timestamp.Before(workoutStart) {
+			workoutStart = dp.Timestamp
+		}
+	}
+
+	// sorts lapData, as NewWindow requires
+	avgHR, err := zones.AverageHeartRate(lapData, averaging)
+	if err != nil {
+		return 0, types.Lap{}, zones.Window{}, fmt.Errorf("lap %d has no heart rate data", lapNumber)
+	}
+
+	return int(math.Round(avgHR)), lap, zones.NewWindow(lapData, workoutStart, averaging), nil
+}
+
+// The data points recorded during a lap
+func lapHRData(hrData []types.HRDataPoint, lap types.Lap) []types.HRDataPoint {
+	var lapData []types.HRDataPoint
+	for _, dp := range hrData {
+		if !dp.Timestamp.Before(lap.StartTime) && !dp.Timestamp.After(lap.EndTime) {
+			lapData = append(lapData, dp)
+		}
 	}
 
-	fmt.Fprint(stdout, formatOutput(zones))
-	return 0
+	return lapData
 }
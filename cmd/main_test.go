@@ -2,9 +2,16 @@ package main
 
 import (
 	"bytes"
+	"compress/gzip"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+	"zone-finder/filter"
+	"zone-finder/load"
+	"zone-finder/types"
 	"zone-finder/zones"
 )
 
@@ -12,12 +19,12 @@ func TestFormatOutput(t *testing.T) {
 	// Mock zone result
 	result := zones.HeartRateZones{
 		LTHR: 172,
-		Zones: [5]zones.Zone{
+		Zones: []zones.Zone{
 			{Number: 1, Min: 0, Max: 137},
 			{Number: 2, Min: 138, Max: 151},
 			{Number: 3, Min: 152, Max: 162},
 			{Number: 4, Min: 163, Max: 172},
-			{Number: 5, Min: 173, Max: 220},
+			{Number: 5, Min: 173},
 		},
 	}
 
@@ -46,15 +53,145 @@ func TestFormatOutput(t *testing.T) {
 	}
 }
 
+func TestFormatOutput_Sport(t *testing.T) {
+	result := zones.CalculateZonesForSport(160, types.SportCycling)
+
+	output := formatOutput(result)
+
+	if !strings.HasPrefix(output, "Sport: cycling\n") {
+		t.Errorf("Expected output to start with the sport, got:\n%s", output)
+	}
+
+	if strings.Contains(formatOutput(zones.CalculateZones(160)), "Sport") {
+		t.Error("Expected no sport line for zones without a sport")
+	}
+}
+
+func TestFormatWindow(t *testing.T) {
+	start := time.Date(2025, 5, 3, 8, 12, 5, 0, time.UTC)
+	window := zones.Window{
+		Start:   start,
+		End:     start.Add(20 * time.Minute),
+		Offset:  12*time.Minute + 5*time.Second,
+		Samples: 1201,
+		MinHR:   160,
+		MaxHR:   182,
+		AvgHR:   173.62,
+	}
+
+	want := "Effort: 12m5s-32m5s into the workout (08:12:05-08:32:05 UTC)\n" +
+		"Effort HR: avg 173.6, min 160, max 182 over 1201 samples\n"
+	if got := formatWindow(window); got != want {
+		t.Errorf("formatWindow() = %q, want %q", got, want)
+	}
+
+	output := formatOutput(zones.HeartRateZones{LTHR: 174, Window: &window})
+	if !strings.Contains(output, want) {
+		t.Errorf("Expected output to include the window, got:\n%s", output)
+	}
+}
+
+func TestFormatGaps(t *testing.T) {
+	start := time.Date(2025, 5, 3, 8, 0, 0, 0, time.UTC)
+	hrData := []types.HRDataPoint{
+		{Timestamp: start, HeartRate: 120},
+		{Timestamp: start.Add(12 * time.Minute), HeartRate: 150},
+	}
+	gaps := []zones.Gap{
+		{Start: start.Add(2 * time.Minute), End: start.Add(12 * time.Minute)},
+	}
+
+	want := "Gaps: 1 longer than 10s\n  2m0s-12m0s (10m0s)\n"
+	if got := formatGaps(hrData, gaps, 10*time.Second); got != want {
+		t.Errorf("formatGaps() = %q, want %q", got, want)
+	}
+
+	if got := formatGaps(hrData, nil, 10*time.Second); got != "" {
+		t.Errorf("formatGaps() = %q for no gaps, want empty", got)
+	}
+}
+
+func TestFormatTimeInZones(t *testing.T) {
+	distribution := zones.TimeInZones{
+		Zones: []zones.ZoneTime{
+			{Zone: zones.Zone{Number: 1}, Duration: 30 * time.Minute},
+			{Zone: zones.Zone{Number: 2, Name: "5a"}, Duration: 5*time.Minute + 30*time.Second},
+		},
+		Below: 4*time.Minute + 30*time.Second,
+		Total: 40 * time.Minute,
+	}
+
+	want := "Time in zones:\n" +
+		"  Below zones  4m30s   11.2%\n" +
+		"  Zone 1       30m0s   75.0%\n" +
+		"  Zone 5a      5m30s   13.8%\n"
+	if got := formatTimeInZones(distribution); got != want {
+		t.Errorf("formatTimeInZones() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatLoad(t *testing.T) {
+	tests := []struct {
+		load load.Load
+		want string
+	}{
+		{
+			load: load.Load{HRTSS: 82.44, BanisterTRIMP: 101.2, EdwardsTRIMP: 151.25},
+			want: "Load: approx. hrTSS 82.4, Banister TRIMP 101.2, Edwards TRIMP 151.2\n",
+		},
+		{
+			load: load.Load{HRTSS: 82.44, EdwardsTRIMP: 151.25},
+			want: "Load: approx. hrTSS 82.4, Edwards TRIMP 151.2\n",
+		},
+		{
+			load: load.Load{},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := formatLoad(tt.load); got != tt.want {
+			t.Errorf("formatLoad() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestFormatFilterResults(t *testing.T) {
+	results := []filter.Result{
+		{Stage: "range", Removed: 1},
+		{Stage: "rate", Removed: 5},
+		{Stage: "hampel", Removed: 0},
+	}
+
+	want := "6 samples (range 1, rate 5, hampel 0)"
+	if got := formatFilterResults(results); got != want {
+		t.Errorf("formatFilterResults() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatOutput_MaxHR(t *testing.T) {
+	output := formatOutput(zones.CalculateMaxHRZones(190))
+
+	for _, want := range []string{"Max HR: 190 bpm\n", "Zone 1: 95-113\n", "Zone 5: 171-190\n"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	if strings.Contains(output, "LTHR") {
+		t.Errorf("Expected no LTHR for max HR zones, got:\n%s", output)
+	}
+}
+
 func TestFormatOutput_Structure(t *testing.T) {
 	result := zones.HeartRateZones{
 		LTHR: 160,
-		Zones: [5]zones.Zone{
+		Zones: []zones.Zone{
 			{Number: 1, Min: 0, Max: 127},
 			{Number: 2, Min: 128, Max: 141},
 			{Number: 3, Min: 142, Max: 150},
 			{Number: 4, Min: 151, Max: 160},
-			{Number: 5, Min: 161, Max: 220},
+			{Number: 5, Min: 161},
 		},
 	}
 
@@ -127,6 +264,13 @@ func TestRun(t *testing.T) {
 			wantStdout:   true,
 			wantStderr:   false,
 		},
+		{
+			name:         "valid GPX file",
+			args:         []string{"zone-finder", "./testdata/outside_run_armband.gpx"},
+			wantExitCode: 0,
+			wantStdout:   true,
+			wantStderr:   false,
+		},
 		{
 			name:         "invalid file",
 			args:         []string{"zone-finder", "nonexistent.tcx"},
@@ -138,7 +282,7 @@ func TestRun(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var stdout, stderr bytes.Buffer
-			exitCode := run(tt.args, &stdout, &stderr)
+			exitCode := run(tt.args, strings.NewReader(""), &stdout, &stderr)
 
 			// Should exit with correct code
 			if exitCode != tt.wantExitCode {
@@ -170,6 +314,14 @@ func TestRun(t *testing.T) {
 				if !strings.Contains(output, "Zone") {
 					t.Error("Expected output to contain zone information")
 				}
+
+				if !strings.Contains(output, "Time in zones:\n") {
+					t.Error("Expected output to contain time in zones")
+				}
+
+				if !strings.Contains(output, "Load: approx. hrTSS ") {
+					t.Error("Expected output to contain training load")
+				}
 			}
 		})
 	}
@@ -197,10 +349,17 @@ func TestShowUsage(t *testing.T) {
 		t.Error("Expected usage to contain 'Usage:' header")
 	}
 
-	// Should mention both TCX and FIT formats
+	// Should be formatted without stray verbs
+	if strings.Contains(output, "%!") {
+		t.Errorf("Expected usage to be formatted cleanly, got:\n%s", output)
+	}
+
+	// Should mention the supported formats
 	output = strings.ToLower(output)
-	if !strings.Contains(output, "tcx") && !strings.Contains(output, "fit") {
-		t.Log("Consider mentioning supported file formats (TCX, FIT)")
+	for _, format := range []string{"tcx", "fit", "gpx"} {
+		if !strings.Contains(output, format) {
+			t.Errorf("Expected usage to mention %s format", format)
+		}
 	}
 }
 
@@ -223,7 +382,7 @@ func TestRun_HelpFlag(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var stdout, stderr bytes.Buffer
 
-			exitCode := run(tt.args, &stdout, &stderr)
+			exitCode := run(tt.args, strings.NewReader(""), &stdout, &stderr)
 
 			// Should exit successfully (help is not an error)
 			if exitCode != 0 {
@@ -252,7 +411,7 @@ func TestRun_HelpFlag(t *testing.T) {
 func TestRun_NoArgs_ShowsUsage(t *testing.T) {
 	var stdout, stderr bytes.Buffer
 
-	exitCode := run([]string{"zone-finder"}, &stdout, &stderr)
+	exitCode := run([]string{"zone-finder"}, strings.NewReader(""), &stdout, &stderr)
 
 	// Should exit with error code (missing required argument)
 	if exitCode == 0 {
@@ -303,7 +462,7 @@ func TestValidateArgs_HelpFlags(t *testing.T) {
 func TestRun_UnsupportedFileFormat(t *testing.T) {
 	var stdout, stderr bytes.Buffer
 
-	exitCode := run([]string{"zone-finder", "workout.gpx"}, &stdout, &stderr)
+	exitCode := run([]string{"zone-finder", "./testdata/route.kml"}, strings.NewReader(""), &stdout, &stderr)
 
 	// Should exit with error
 	if exitCode == 0 {
@@ -321,3 +480,1125 @@ func TestRun_UnsupportedFileFormat(t *testing.T) {
 		t.Error("Expected error to mention unsupported format")
 	}
 }
+
+func TestRun_MisnamedFileFormat(t *testing.T) {
+	data, err := os.ReadFile("./testdata/route.kml")
+	if err != nil {
+		t.Fatalf("failed to read route.kml: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "route.gpx")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	exitCode := run([]string{"zone-finder", path}, strings.NewReader(""), &stdout, &stderr)
+	if exitCode != exitError {
+		t.Errorf("Expected exit code %d, got %d", exitError, exitCode)
+	}
+
+	if want := "unsupported format (XML root kml)"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("Expected error containing %q, got:\n%s", want, stderr.String())
+	}
+}
+
+func TestRun_UndetectableStdin(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		wantErr  string
+		wantHint bool
+	}{
+		{
+			name:    "empty stdin",
+			args:    []string{"zone-finder", "-"},
+			wantErr: "failed to parse workout file: workout file is empty\n",
+		},
+		{
+			name:    "empty stdin with a format",
+			args:    []string{"zone-finder", "--format", "fit", "-"},
+			wantErr: "failed to parse workout file: workout file is empty\n",
+		},
+		{
+			name:     "unknown contents",
+			args:     []string{"zone-finder", "-"},
+			stdin:    "hello",
+			wantErr:  "failed to parse workout file: could not detect workout format\n",
+			wantHint: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+
+			if exitCode != exitError {
+				t.Errorf("Expected exit code %d, got %d", exitError, exitCode)
+			}
+
+			if !strings.Contains(stderr.String(), tt.wantErr) {
+				t.Errorf("Expected stderr to contain %q, got:\n%s", tt.wantErr, stderr.String())
+			}
+
+			if hint := strings.Contains(stderr.String(), "hint: pass --format with one of "); hint != tt.wantHint {
+				t.Errorf("Expected --format hint %v, got:\n%s", tt.wantHint, stderr.String())
+			}
+		})
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantPath   string
+		wantFormat string
+		wantErr    bool
+	}{
+		{
+			name:     "file path only",
+			args:     []string{"zone-finder", "run.fit"},
+			wantPath: "run.fit",
+		},
+		{
+			name:       "stdin with format",
+			args:       []string{"zone-finder", "--format", "tcx", "-"},
+			wantPath:   "-",
+			wantFormat: "tcx",
+		},
+		{
+			name:     "stdin without format",
+			args:     []string{"zone-finder", "-"},
+			wantPath: "-",
+		},
+		{
+			name:    "unknown flag",
+			args:    []string{"zone-finder", "--nope", "run.fit"},
+			wantErr: true,
+		},
+		{
+			name:     "gap policy",
+			args:     []string{"zone-finder", "--gap-threshold", "30s", "--min-coverage", "75", "run.fit"},
+			wantPath: "run.fit",
+		},
+		{
+			name:    "negative gap threshold",
+			args:    []string{"zone-finder", "--gap-threshold", "-5s", "run.fit"},
+			wantErr: true,
+		},
+		{
+			name:    "coverage over 100 percent",
+			args:    []string{"zone-finder", "--min-coverage", "120", "run.fit"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseArgs(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if opts.path != tt.wantPath || opts.format != tt.wantFormat {
+				t.Errorf("parseArgs() = %+v, want path %q format %q", opts, tt.wantPath, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestRun_Stdin(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		path       string
+		wantFormat string
+	}{
+		{
+			name:       "explicit format",
+			args:       []string{"zone-finder", "--format", "fit", "-"},
+			path:       "./testdata/outside_run_armband.fit",
+			wantFormat: "Format: fit (detected by --format)",
+		},
+		{
+			name:       "sniffed format",
+			args:       []string{"zone-finder", "-"},
+			path:       "./testdata/outside_run_armband.tcx",
+			wantFormat: "Format: tcx (detected by content)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile(tt.path)
+			if err != nil {
+				t.Fatalf("failed to read workout file: %v", err)
+			}
+
+			var stdout, stderr bytes.Buffer
+			exitCode := run(tt.args, bytes.NewReader(data), &stdout, &stderr)
+
+			if exitCode != 0 {
+				t.Fatalf("Expected exit code 0, got %d: %s", exitCode, stderr.String())
+			}
+
+			if !strings.Contains(stdout.String(), tt.wantFormat) {
+				t.Errorf("Expected output to contain %q, got:\n%s", tt.wantFormat, stdout.String())
+			}
+
+			if !strings.Contains(stdout.String(), "LTHR") {
+				t.Error("Expected output to contain LTHR")
+			}
+		})
+	}
+}
+
+func TestRun_GzipExport(t *testing.T) {
+	data, err := os.ReadFile("./testdata/outside_run_armband.fit")
+	if err != nil {
+		t.Fatalf("failed to read FIT file: %v", err)
+	}
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write(data)
+	writer.Close()
+
+	path := filepath.Join(t.TempDir(), "activity.fit.gz")
+	if err := os.WriteFile(path, compressed.Bytes(), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantFormat string
+	}{
+		{
+			name:       "detected",
+			args:       []string{"zone-finder", path},
+			wantFormat: "Format: fit in gzip (detected by content)",
+		},
+		{
+			name:       "format of the compressed workout",
+			args:       []string{"zone-finder", "--format", "fit", path},
+			wantFormat: "Format: fit in gzip (detected by --format)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			if exitCode != 0 {
+				t.Fatalf("Expected exit code 0, got %d: %s", exitCode, stderr.String())
+			}
+
+			if !strings.Contains(stdout.String(), tt.wantFormat) {
+				t.Errorf("Expected output to contain %q, got:\n%s", tt.wantFormat, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRun_Lap(t *testing.T) {
+	noHeartRate := filepath.Join(t.TempDir(), "no-heart-rate.tcx")
+	tcx := `<?xml version="1.0" encoding="UTF-8"?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2">
+ <Activities>
+  <Activity Sport="Running">
+   <Id>2025-04-26T15:15:28Z</Id>
+   <Lap StartTime="2025-04-26T15:15:28Z">
+    <TotalTimeSeconds>1800</TotalTimeSeconds>
+    <Track>
+     <Trackpoint><Time>2025-04-26T15:15:28Z</Time></Trackpoint>
+     <Trackpoint><Time>2025-04-26T15:45:28Z</Time></Trackpoint>
+    </Track>
+   </Lap>
+  </Activity>
+ </Activities>
+</TrainingCenterDatabase>`
+	if err := os.WriteFile(noHeartRate, []byte(tcx), 0o644); err != nil {
+		t.Fatalf("failed to write workout file: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		args         []string
+		wantExitCode int
+		wantOutput   string
+	}{
+		{
+			name:         "threshold lap",
+			args:         []string{"zone-finder", "--lap", "4", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   "Effort HR: avg 174.8, min 172, max 177 over 402 samples\nLTHR: 175 bpm",
+		},
+		{
+			name:         "lap out of range",
+			args:         []string{"zone-finder", "--lap", "9", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "negative lap",
+			args:         []string{"zone-finder", "--lap", "-1", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "lap without heart rate data",
+			args:         []string{"zone-finder", "--lap", "1", noHeartRate},
+			wantExitCode: exitNoHRData,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			if exitCode != tt.wantExitCode {
+				t.Fatalf("Expected exit code %d, got %d: %s", tt.wantExitCode, exitCode, stderr.String())
+			}
+
+			if !strings.Contains(stdout.String(), tt.wantOutput) {
+				t.Errorf("Expected output to contain %q, got:\n%s", tt.wantOutput, stdout.String())
+			}
+		})
+	}
+}
+
+// Writes a TCX holding the armband run twice, the second copy as a ride
+func writeMultiActivityTCX(t *testing.T, secondId string) string {
+	t.Helper()
+
+	data, err := os.ReadFile("./testdata/outside_run_armband.tcx")
+	if err != nil {
+		t.Fatalf("failed to read TCX file: %v", err)
+	}
+
+	contents := string(data)
+	start := strings.Index(contents, "<Activity ")
+	end := strings.Index(contents, "</Activity>") + len("</Activity>")
+	activity := contents[start:end]
+
+	second := strings.Replace(activity, `Sport="Running"`, `Sport="Biking"`, 1)
+	idStart := strings.Index(second, "<Id>") + len("<Id>")
+	idEnd := strings.Index(second, "</Id>")
+	second = second[:idStart] + secondId + second[idEnd:]
+
+	path := filepath.Join(t.TempDir(), "multisport.tcx")
+	if err := os.WriteFile(path, []byte(contents[:end]+"\n"+second+contents[end:]), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+
+	return path
+}
+
+func TestRun_MultipleActivities(t *testing.T) {
+	path := writeMultiActivityTCX(t, "2024-06-02T09:00:00.000Z")
+	duplicateIds := writeMultiActivityTCX(t, "2024-06-01T11:15:00.000Z")
+
+	tests := []struct {
+		name         string
+		args         []string
+		wantExitCode int
+		wantStdout   string
+		wantStderr   string
+	}{
+		{
+			name:         "no selector",
+			args:         []string{"zone-finder", path},
+			wantExitCode: 1,
+			wantStderr:   "contains 2 activities",
+		},
+		{
+			name:         "by number",
+			args:         []string{"zone-finder", "--activity", "2", path},
+			wantExitCode: 0,
+			wantStdout:   "Sport: cycling",
+		},
+		{
+			name:         "by Id",
+			args:         []string{"zone-finder", "--activity", "2024-06-02T09:00:00.000Z", path},
+			wantExitCode: 0,
+			wantStdout:   "Activity: 2024-06-02T09:00:00.000Z",
+		},
+		{
+			name:         "number out of range",
+			args:         []string{"zone-finder", "--activity", "3", path},
+			wantExitCode: 1,
+			wantStderr:   "out of range",
+		},
+		{
+			name:         "unknown Id",
+			args:         []string{"zone-finder", "--activity", "2020-01-01T00:00:00.000Z", path},
+			wantExitCode: 1,
+			wantStderr:   "no activity with Id",
+		},
+		{
+			name:         "ambiguous Id",
+			args:         []string{"zone-finder", "--activity", "2024-06-01T11:15:00.000Z", duplicateIds},
+			wantExitCode: 1,
+			wantStderr:   "choose one by number",
+		},
+		{
+			name:         "selector for a single-activity file",
+			args:         []string{"zone-finder", "--activity", "2", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+			wantStderr:   "single activity",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			if exitCode != tt.wantExitCode {
+				t.Fatalf("Expected exit code %d, got %d: %s", tt.wantExitCode, exitCode, stderr.String())
+			}
+
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("Expected stdout to contain %q, got:\n%s", tt.wantStdout, stdout.String())
+			}
+
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("Expected stderr to contain %q, got:\n%s", tt.wantStderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRun_Protocol(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantExitCode int
+		wantOutput   string
+	}{
+		{
+			name:         "default protocol",
+			args:         []string{"zone-finder", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   "Protocol: best-window",
+		},
+		{
+			name:         "selected window",
+			args:         []string{"zone-finder", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   "Effort: 12m2s-32m0s into the workout",
+		},
+		{
+			name:         "friel",
+			args:         []string{"zone-finder", "--protocol", "friel", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   "Protocol: friel",
+		},
+		{
+			name:         "95 percent",
+			args:         []string{"zone-finder", "--protocol", "95-percent", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   "Protocol: 95-percent",
+		},
+		{
+			name:         "unknown protocol",
+			args:         []string{"zone-finder", "--protocol", "ramp", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "protocol with lap",
+			args:         []string{"zone-finder", "--protocol", "friel", "--lap", "2", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			if exitCode != tt.wantExitCode {
+				t.Fatalf("Expected exit code %d, got %d: %s", tt.wantExitCode, exitCode, stderr.String())
+			}
+
+			if !strings.Contains(stdout.String(), tt.wantOutput) {
+				t.Errorf("Expected output to contain %q, got:\n%s", tt.wantOutput, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRun_Averaging(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantExitCode int
+		wantOutput   string
+	}{
+		{
+			name:         "time weighted",
+			args:         []string{"zone-finder", "--averaging", "time", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   "Averaging: time",
+		},
+		{
+			name:         "time weighted lap",
+			args:         []string{"zone-finder", "--averaging", "time", "--lap", "2", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   "Averaging: time",
+		},
+		{
+			name:         "unknown averaging",
+			args:         []string{"zone-finder", "--averaging", "median", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			if exitCode != tt.wantExitCode {
+				t.Fatalf("Expected exit code %d, got %d: %s", tt.wantExitCode, exitCode, stderr.String())
+			}
+
+			if !strings.Contains(stdout.String(), tt.wantOutput) {
+				t.Errorf("Expected output to contain %q, got:\n%s", tt.wantOutput, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRun_Filter(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantFilter bool
+	}{
+		{
+			name:       "filtered by default",
+			args:       []string{"zone-finder", "./testdata/outside_run_armband.fit"},
+			wantFilter: true,
+		},
+		{
+			name:       "no filter",
+			args:       []string{"zone-finder", "--no-filter", "./testdata/outside_run_armband.fit"},
+			wantFilter: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			if exitCode != 0 {
+				t.Fatalf("Expected exit code 0, got %d: %s", exitCode, stderr.String())
+			}
+
+			if got := strings.Contains(stdout.String(), "Filtered: "); got != tt.wantFilter {
+				t.Errorf("Expected filter report %v, got:\n%s", tt.wantFilter, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRun_Window(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantExitCode int
+		wantOutput   string
+		wantStderr   string
+	}{
+		{
+			name:         "8 minute field test",
+			args:         []string{"zone-finder", "--window", "8m", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   "Window: 8m0s",
+		},
+		{
+			name:         "window longer than the workout",
+			args:         []string{"zone-finder", "--window", "3h", "./testdata/outside_run_armband.fit"},
+			wantExitCode: exitTooShort,
+		},
+		{
+			name:         "zero window",
+			args:         []string{"zone-finder", "--window", "0s", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+			wantStderr:   "invalid --window 0s: must be longer than 2s",
+		},
+		{
+			name:         "window no longer than the default tolerance",
+			args:         []string{"zone-finder", "--window", "1s", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+			wantStderr:   "invalid --window 1s: must be longer than 2s",
+		},
+		{
+			name:         "sub-minute window",
+			args:         []string{"zone-finder", "--window", "30s", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   "Window: 30s",
+		},
+		{
+			name:         "window with lap",
+			args:         []string{"zone-finder", "--window", "8m", "--lap", "2", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "window with friel",
+			args:         []string{"zone-finder", "--window", "8m", "--protocol", "friel", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			if exitCode != tt.wantExitCode {
+				t.Fatalf("Expected exit code %d, got %d: %s", tt.wantExitCode, exitCode, stderr.String())
+			}
+
+			if !strings.Contains(stdout.String(), tt.wantOutput) {
+				t.Errorf("Expected output to contain %q, got:\n%s", tt.wantOutput, stdout.String())
+			}
+
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("Expected stderr to contain %q, got:\n%s", tt.wantStderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRun_ExitCodes(t *testing.T) {
+	noHeartRate := filepath.Join(t.TempDir(), "no-heart-rate.gpx")
+	gpx := `<?xml version="1.0" encoding="UTF-8"?>
+<gpx creator="phone" version="1.1" xmlns="http://www.topografix.com/GPX/1/1">
+ <trk>
+  <trkseg>
+   <trkpt lat="36.0664" lon="-78.9482"><time>2025-04-26T15:15:28Z</time></trkpt>
+   <trkpt lat="36.0665" lon="-78.9483"><time>2025-04-26T15:45:28Z</time></trkpt>
+  </trkseg>
+ </trk>
+</gpx>`
+	if err := os.WriteFile(noHeartRate, []byte(gpx), 0o644); err != nil {
+		t.Fatalf("failed to write workout file: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		args         []string
+		wantExitCode int
+		wantHint     string
+	}{
+		{
+			name:         "no heart rate data",
+			args:         []string{"zone-finder", noHeartRate},
+			wantExitCode: exitNoHRData,
+			wantHint:     "no heart rate data",
+		},
+		{
+			name:         "workout shorter than the window",
+			args:         []string{"zone-finder", "--window", "45m", "./testdata/outside_run_armband.fit"},
+			wantExitCode: exitTooShort,
+			wantHint:     "your workout was 32m0s",
+		},
+		{
+			name:         "workout shorter than the friel time trial",
+			args:         []string{"zone-finder", "--protocol", "friel", "./testdata/treadmill_run_watch.fit"},
+			wantExitCode: exitTooShort,
+			wantHint:     "try --protocol best-window",
+		},
+		{
+			name:         "every window has gaps",
+			args:         []string{"zone-finder", "--gap-threshold", "1ns", "./testdata/outside_run_armband.fit"},
+			wantExitCode: exitNoValidWindow,
+			wantHint:     "lower --min-coverage",
+		},
+		{
+			name:         "no minimum coverage accepts windows with gaps",
+			args:         []string{"zone-finder", "--gap-threshold", "1ns", "--min-coverage", "0", "./testdata/outside_run_armband.fit"},
+			wantExitCode: exitOK,
+		},
+		{
+			name:         "missing file",
+			args:         []string{"zone-finder", "./testdata/missing.fit"},
+			wantExitCode: exitError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			if exitCode != tt.wantExitCode {
+				t.Fatalf("Expected exit code %d, got %d: %s", tt.wantExitCode, exitCode, stderr.String())
+			}
+
+			if !strings.Contains(stderr.String(), tt.wantHint) {
+				t.Errorf("Expected hint containing %q, got:\n%s", tt.wantHint, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRun_MaxHRMethods(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantExitCode int
+		wantOutput   []string
+		wantWarning  string
+	}{
+		{
+			name:         "detected max",
+			args:         []string{"zone-finder", "--method", "max-hr", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Method: max-hr", "Max HR: 178 bpm", "Zone 5: 160-178"},
+		},
+		{
+			name:         "given max",
+			args:         []string{"zone-finder", "--method", "max-hr", "--max-hr", "190", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Max HR: 190 bpm", "Zone 1: 95-113", "Zone 5: 171-190"},
+		},
+		{
+			name:         "heart rate reserve",
+			args:         []string{"zone-finder", "--method", "hrr", "--resting-hr", "48", "--max-hr", "192", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Method: hrr", "Max HR: 192 bpm", "Resting HR: 48 bpm (given)", "Zone 1: 120-133", "Zone 5: 178-192"},
+		},
+		{
+			name:         "heart rate reserve with estimated resting HR",
+			args:         []string{"zone-finder", "--method", "hrr", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Resting HR: 154 bpm (estimated from workout)"},
+			wantWarning:  "warning: estimated resting HR 154 bpm is 87% of max HR 178 bpm",
+		},
+		{
+			name:         "resting HR without hrr method",
+			args:         []string{"zone-finder", "--method", "max-hr", "--resting-hr", "48", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "resting HR above max",
+			args:         []string{"zone-finder", "--method", "hrr", "--resting-hr", "200", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "max ends the top lthr zone",
+			args:         []string{"zone-finder", "--max-hr", "190", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"LTHR: 174 bpm", "Max HR: 190 bpm (given)", "Zone 5: 175-190"},
+		},
+		{
+			name:         "max-hr method with lap",
+			args:         []string{"zone-finder", "--method", "max-hr", "--lap", "2", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "unknown method",
+			args:         []string{"zone-finder", "--method", "vo2", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			if exitCode != tt.wantExitCode {
+				t.Fatalf("Expected exit code %d, got %d: %s", tt.wantExitCode, exitCode, stderr.String())
+			}
+
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("Expected output to contain %q, got:\n%s", want, stdout.String())
+				}
+			}
+
+			if !strings.Contains(stderr.String(), tt.wantWarning) {
+				t.Errorf("Expected a warning containing %q, got:\n%s", tt.wantWarning, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRun_Scheme(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantExitCode int
+		wantOutput   []string
+	}{
+		{
+			name:         "friel for a run",
+			args:         []string{"zone-finder", "--scheme", "friel", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Scheme: friel-run", "Zone 4: 165-173", "Zone 5a: 174-178", "Zone 5b: 179-185", "Zone 5c: 186+"},
+		},
+		{
+			name:         "friel bike zones from a lap",
+			args:         []string{"zone-finder", "--scheme", "friel-bike", "--lap", "2", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Scheme: friel-bike", "Zone 5b: "},
+		},
+		{
+			name:         "unknown scheme",
+			args:         []string{"zone-finder", "--scheme", "coggan", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "scheme with max-hr method",
+			args:         []string{"zone-finder", "--scheme", "friel", "--method", "max-hr", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "lthr scheme from a file",
+			args:         []string{"zone-finder", "--schemes", "./testdata/schemes.json", "--scheme", "coach", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Scheme: coach", "LTHR: 174 bpm", "Zone recovery: 0-142", "Zone threshold: 164-173", "Zone vo2max: 174+"},
+		},
+		{
+			name:         "hrr scheme from a file",
+			args:         []string{"zone-finder", "--schemes", "./testdata/schemes.json", "--scheme", "karvonen-3", "--resting-hr", "50", "--max-hr", "190", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Method: hrr", "Scheme: karvonen-3", "Zone easy: 134-147", "Zone hard: 162-190"},
+		},
+		{
+			name:         "built-in scheme with a schemes file",
+			args:         []string{"zone-finder", "--schemes", "./testdata/schemes.json", "--scheme", "friel", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Scheme: friel-run"},
+		},
+		{
+			name:         "hrr scheme with lap",
+			args:         []string{"zone-finder", "--schemes", "./testdata/schemes.json", "--scheme", "karvonen-3", "--lap", "2", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "max-hr with lthr scheme",
+			args:         []string{"zone-finder", "--schemes", "./testdata/schemes.json", "--scheme", "coach", "--max-hr", "190", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Zone vo2max: 174-190"},
+		},
+		{
+			name:         "schemes file without a scheme",
+			args:         []string{"zone-finder", "--schemes", "./testdata/schemes.json", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "missing schemes file",
+			args:         []string{"zone-finder", "--schemes", "./testdata/missing.json", "--scheme", "coach", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			if exitCode != tt.wantExitCode {
+				t.Fatalf("Expected exit code %d, got %d: %s", tt.wantExitCode, exitCode, stderr.String())
+			}
+
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("Expected output to contain %q, got:\n%s", want, stdout.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRun_MaxHRSource(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantExitCode int
+		wantOutput   []string
+		wantWarnings []string
+	}{
+		{
+			name:         "lthr zones are open-ended by default",
+			args:         []string{"zone-finder", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Zone 5: 175+"},
+		},
+		{
+			name:         "observed",
+			args:         []string{"zone-finder", "--max-hr-source", "observed", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Max HR: 178 bpm (observed peak)", "Zone 5: 175-178"},
+		},
+		{
+			name:         "tanaka",
+			args:         []string{"zone-finder", "--max-hr-source", "tanaka", "--age", "30", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Max HR: 187 bpm (Tanaka formula, 208 - 0.7 x age)", "Zone 5: 175-187"},
+		},
+		{
+			name:         "fox with max-hr method",
+			args:         []string{"zone-finder", "--method", "max-hr", "--max-hr-source", "fox", "--age", "30", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Max HR: 190 bpm (Fox formula, 220 - age)", "Zone 5: 171-190"},
+		},
+		{
+			name:         "formula below lthr",
+			args:         []string{"zone-finder", "--max-hr-source", "gulati", "--age", "45", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+			wantWarnings: []string{"max HR 166 (gulati) is below LTHR 174"},
+		},
+		{
+			name:         "given max below lthr",
+			args:         []string{"zone-finder", "--max-hr", "150", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+			wantWarnings: []string{"max HR 150 (given) is below LTHR 174"},
+		},
+		{
+			name:         "given max at lthr leaves out the top zone",
+			args:         []string{"zone-finder", "--max-hr", "174", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Max HR: 174 bpm", "Zone 4: 165-174\n"},
+			wantWarnings: []string{"warning: zone 5 starts at 175 bpm, above the max HR of 174 bpm (given); it was left out"},
+		},
+		{
+			name:         "implausible age",
+			args:         []string{"zone-finder", "--max-hr-source", "fox", "--age", "300", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+			wantWarnings: []string{"invalid age 300: must be between 10 and 100"},
+		},
+		{
+			name:         "friel zones with an observed max",
+			args:         []string{"zone-finder", "--scheme", "friel", "--max-hr-source", "observed", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Max HR: 178 bpm (observed peak)", "Zone 5a: 174-178\n"},
+			wantWarnings: []string{"zone 5b starts at 179 bpm", "zone 5c starts at "},
+		},
+		{
+			name:         "formula without age",
+			args:         []string{"zone-finder", "--max-hr-source", "fox", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "age without formula",
+			args:         []string{"zone-finder", "--age", "30", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "max-hr with a source",
+			args:         []string{"zone-finder", "--max-hr", "190", "--max-hr-source", "observed", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "unknown source",
+			args:         []string{"zone-finder", "--max-hr-source", "astrand", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			if exitCode != tt.wantExitCode {
+				t.Fatalf("Expected exit code %d, got %d: %s", tt.wantExitCode, exitCode, stderr.String())
+			}
+
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("Expected output to contain %q, got:\n%s", want, stdout.String())
+				}
+			}
+
+			for _, want := range tt.wantWarnings {
+				if !strings.Contains(stderr.String(), want) {
+					t.Errorf("Expected a warning containing %q, got:\n%s", want, stderr.String())
+				}
+			}
+			if len(tt.wantWarnings) == 0 && strings.Contains(stderr.String(), "warning:") {
+				t.Errorf("Expected no warnings, got:\n%s", stderr.String())
+			}
+		})
+	}
+}
+
+func TestRun_Analyze(t *testing.T) {
+	profilePath := filepath.Join(t.TempDir(), "athlete.json")
+	if err := os.WriteFile(profilePath, []byte(`{"method": "hrr", "max_hr": 192, "resting_hr": 48}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		args         []string
+		wantExitCode int
+		wantOutput   []string
+	}{
+		{
+			name:         "known lthr",
+			args:         []string{"zone-finder", "analyze", "--lthr", "172", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput: []string{
+				"LTHR: 172 bpm",
+				"Zone 5: 173+",
+				"Average HR: 172 bpm (zone 4)",
+				"Load: approx. hrTSS 53.1\n",
+				"Time in zones:\n",
+				"Lap 1    6m22s  avg 164 bpm  zone 4\n",
+				"Lap 5    6m10s  avg 176 bpm  zone 5\n",
+			},
+		},
+		{
+			name:         "known lthr isn't recalculated",
+			args:         []string{"zone-finder", "analyze", "--lthr", "150", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"LTHR: 150 bpm", "Lap 1    6m22s  avg 164 bpm  zone 5\n"},
+		},
+		{
+			name:         "scheme",
+			args:         []string{"zone-finder", "analyze", "--lthr", "172", "--scheme", "friel", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Scheme: friel-run", "Lap 2    6m28s  avg 172 bpm  zone 5a\n"},
+		},
+		{
+			name:         "profile",
+			args:         []string{"zone-finder", "analyze", "--profile", profilePath, "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Max HR: 192 bpm (given)", "Resting HR: 48 bpm", "Load: Banister TRIMP "},
+		},
+		{
+			name:         "flags override the profile",
+			args:         []string{"zone-finder", "analyze", "--profile", profilePath, "--max-hr", "200", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Max HR: 200 bpm (given)", "Resting HR: 48 bpm"},
+		},
+		{
+			name:         "banister trimp for lthr zones",
+			args:         []string{"zone-finder", "analyze", "--lthr", "172", "--max-hr", "192", "--resting-hr", "48", "--sex", "female", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Load: approx. hrTSS 53.1, Banister TRIMP 99.5, Edwards TRIMP 145.1\n"},
+		},
+		{
+			name:         "invalid sex",
+			args:         []string{"zone-finder", "analyze", "--lthr", "172", "--sex", "other", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "lthr zones without lthr",
+			args:         []string{"zone-finder", "analyze", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "hrr zones without resting hr",
+			args:         []string{"zone-finder", "analyze", "--method", "hrr", "--max-hr", "192", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "missing profile",
+			args:         []string{"zone-finder", "analyze", "--profile", "./testdata/missing.json", "./testdata/outside_run_armband.fit"},
+			wantExitCode: 1,
+		},
+		{
+			name:         "help",
+			args:         []string{"zone-finder", "analyze", "--help"},
+			wantExitCode: 0,
+			wantOutput:   []string{"Usage: zone-finder analyze"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			if exitCode != tt.wantExitCode {
+				t.Fatalf("Expected exit code %d, got %d: %s", tt.wantExitCode, exitCode, stderr.String())
+			}
+
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("Expected output to contain %q, got:\n%s", want, stdout.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRun_SaveProfile(t *testing.T) {
+	profilePath := filepath.Join(t.TempDir(), "athlete.json")
+
+	var stdout, stderr bytes.Buffer
+	exitCode := run([]string{"zone-finder", "--save-profile", profilePath, "--scheme", "friel", "./testdata/outside_run_armband.fit"}, strings.NewReader(""), &stdout, &stderr)
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", exitCode, stderr.String())
+	}
+
+	saved, err := loadProfile(profilePath)
+	if err != nil {
+		t.Fatalf("loadProfile() error = %v", err)
+	}
+
+	want := profile{Method: "lthr", LTHR: 174, Scheme: "friel-run", Sport: types.SportRunning}
+	if saved != want {
+		t.Errorf("saved profile = %+v, want %+v", saved, want)
+	}
+
+	stdout.Reset()
+	exitCode = run([]string{"zone-finder", "analyze", "--profile", profilePath, "./testdata/outside_run_armband.tcx"}, strings.NewReader(""), &stdout, &stderr)
+	if exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", exitCode, stderr.String())
+	}
+
+	for _, want := range []string{"Scheme: friel-run", "LTHR: 174 bpm"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("Expected analyze output to contain %q, got:\n%s", want, stdout.String())
+		}
+	}
+
+	// a cycling LTHR mustn't be applied to a run
+	saved.Sport = types.SportCycling
+	if err := saveProfile(profilePath, saved); err != nil {
+		t.Fatalf("saveProfile() error = %v", err)
+	}
+
+	stderr.Reset()
+	exitCode = run([]string{"zone-finder", "analyze", "--profile", profilePath, "./testdata/outside_run_armband.tcx"}, strings.NewReader(""), &stdout, &stderr)
+	if exitCode != exitError {
+		t.Fatalf("Expected exit code %d for a cycling profile, got %d", exitError, exitCode)
+	}
+
+	if want := "the profile is for cycling, but the workout is running; use a profile saved while running"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("Expected error containing %q, got:\n%s", want, stderr.String())
+	}
+
+	// nor is a workout of unknown sport, or a profile saved from one, tied
+	// to a sport
+	for _, sport := range []types.Sport{types.SportOther, ""} {
+		saved.Sport = sport
+		if err := saveProfile(profilePath, saved); err != nil {
+			t.Fatalf("saveProfile() error = %v", err)
+		}
+
+		stderr.Reset()
+		exitCode = run([]string{"zone-finder", "analyze", "--profile", profilePath, "./testdata/outside_run_armband.tcx"}, strings.NewReader(""), &stdout, &stderr)
+		if exitCode != 0 {
+			t.Errorf("Expected exit code 0 for a profile with sport %q, got %d: %s", sport, exitCode, stderr.String())
+		}
+	}
+
+	if got := profileFor(zones.HeartRateZones{LTHR: 170, Sport: types.SportOther}, "").Sport; got != "" {
+		t.Errorf("profileFor() with sport other recorded %q, want \"\"", got)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"zone-finder/types"
+	"zone-finder/zones"
+)
+
+// An athlete's known heart rates and how their zones are calculated, saved
+// with --save-profile so later workouts can be analyzed against the same
+// zones
+type profile struct {
+	Method    string `json:"method,omitempty"`
+	LTHR      int    `json:"lthr,omitempty"`
+	MaxHR     int    `json:"max_hr,omitempty"`
+	RestingHR int    `json:"resting_hr,omitempty"`
+	Scheme    string `json:"scheme,omitempty"`
+	// path of the --schemes file the scheme is defined in, when it isn't
+	// built in
+	Schemes string `json:"schemes,omitempty"`
+	// sport of the test the profile was saved from, as LTHR and zones
+	// differ between sports
+	Sport types.Sport `json:"sport,omitempty"`
+}
+
+func profileFor(result zones.HeartRateZones, schemesPath string) profile {
+	p := profile{
+		Method:    result.Method.String(),
+		LTHR:      result.LTHR,
+		MaxHR:     result.MaxHR,
+		RestingHR: result.RestingHR,
+		Scheme:    result.Scheme,
+		Sport:     profileSport(result.Sport),
+	}
+	if p.Scheme != "" {
+		p.Schemes = schemesPath
+	}
+
+	return p
+}
+
+// Only running and cycling zones are kept apart, so a profile saved from or
+// applied to any other or unknown sport isn't tied to one
+func profileSport(sport types.Sport) types.Sport {
+	switch sport {
+	case types.SportRunning, types.SportCycling:
+		return sport
+	default:
+		return ""
+	}
+}
+
+func loadProfile(path string) (profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return profile{}, err
+	}
+
+	var p profile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return profile{}, fmt.Errorf("failed to decode profile %s: %w", path, err)
+	}
+
+	return p, nil
+}
+
+func saveProfile(path string, p profile) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,171 @@
+// Package filter removes artifacts such as spikes and cadence lock from
+// heart rate data before zones are calculated
+package filter
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"zone-finder/types"
+)
+
+// A step of a Pipeline, returning the data points it keeps from a set sorted
+// by timestamp
+type Stage struct {
+	Name  string
+	Apply func(dataPoints []types.HRDataPoint) []types.HRDataPoint
+}
+
+// Stages applied in order, each to the output of the previous one
+type Pipeline []Stage
+
+// How many data points a stage removed
+type Result struct {
+	Stage   string
+	Removed int
+}
+
+// e.g. "hampel 3"
+func (r Result) String() string {
+	return fmt.Sprintf("%s %d", r.Stage, r.Removed)
+}
+
+// Pipeline suited to optical sensors: implausible heart rates, sudden jumps
+// and short spikes are removed
+func Default() Pipeline {
+	return Pipeline{
+		Range(30, 240),
+		RateOfChange(10),
+		Hampel(15, 3),
+	}
+}
+
+// Apply every stage to a sorted copy of dataPoints, reporting how many data
+// points each removed
+func (p Pipeline) Apply(dataPoints []types.HRDataPoint) ([]types.HRDataPoint, []Result) {
+	filtered := slices.Clone(dataPoints)
+	slices.SortFunc(filtered, func(a, b types.HRDataPoint) int { return a.Timestamp.Compare(b.Timestamp) })
+
+	results := make([]Result, len(p))
+	for i, stage := range p {
+		before := len(filtered)
+		filtered = stage.Apply(filtered)
+		results[i] = Result{Stage: stage.Name, Removed: before - len(filtered)}
+	}
+
+	return filtered, results
+}
+
+// Total number of data points removed
+func Removed(results []Result) int {
+	removed := 0
+	for _, result := range results {
+		removed += result.Removed
+	}
+
+	return removed
+}
+
+// Remove heart rates outside [min, max] bpm, which can't be physiological,
+// e.g. 0 bpm while a strap is losing contact
+func Range(min, max int) Stage {
+	return Stage{
+		Name: "range",
+		Apply: func(dataPoints []types.HRDataPoint) []types.HRDataPoint {
+			var kept []types.HRDataPoint
+			for _, dp := range dataPoints {
+				if dp.HeartRate >= min && dp.HeartRate <= max {
+					kept = append(kept, dp)
+				}
+			}
+
+			return kept
+		},
+	}
+}
+
+// Remove data points whose heart rate changed faster than maxRate bpm per
+// second since the last data point kept. Once the sensor settles, the
+// elapsed time grows until the change is plausible again
+func RateOfChange(maxRate float64) Stage {
+	return Stage{
+		Name: "rate",
+		Apply: func(dataPoints []types.HRDataPoint) []types.HRDataPoint {
+			var kept []types.HRDataPoint
+			for _, dp := range dataPoints {
+				if len(kept) == 0 {
+					kept = append(kept, dp)
+					continue
+				}
+
+				last := kept[len(kept)-1]
+				elapsed := dp.Timestamp.Sub(last.Timestamp)
+				change := math.Abs(float64(dp.HeartRate - last.HeartRate))
+				if elapsed <= 0 {
+					// duplicate timestamps must agree
+					if change == 0 {
+						kept = append(kept, dp)
+					}
+					continue
+				}
+
+				if change/elapsed.Seconds() <= maxRate {
+					kept = append(kept, dp)
+				}
+			}
+
+			return kept
+		},
+	}
+}
+
+// Scales the median absolute deviation to estimate the standard deviation
+// of normally distributed data
+const madScale = 1.4826
+
+// Remove outliers with a Hampel filter: data points more than threshold
+// (scaled) median absolute deviations from the median of the halfWindow
+// data points either side of them. Deviations are at least 1 bpm, so steady
+// efforts with a flat median don't reject every small change
+func Hampel(halfWindow int, threshold float64) Stage {
+	return Stage{
+		Name: "hampel",
+		Apply: func(dataPoints []types.HRDataPoint) []types.HRDataPoint {
+			var kept []types.HRDataPoint
+			window := make([]float64, 0, 2*halfWindow+1)
+			deviations := make([]float64, 0, 2*halfWindow+1)
+
+			for i, dp := range dataPoints {
+				window = window[:0]
+				for _, neighbor := range dataPoints[max(0, i-halfWindow):min(len(dataPoints), i+halfWindow+1)] {
+					window = append(window, float64(neighbor.HeartRate))
+				}
+				center := median(window)
+
+				deviations = deviations[:0]
+				for _, hr := range window {
+					deviations = append(deviations, math.Abs(hr-center))
+				}
+				mad := max(madScale*median(deviations), 1)
+
+				if math.Abs(float64(dp.HeartRate)-center) <= threshold*mad {
+					kept = append(kept, dp)
+				}
+			}
+
+			return kept
+		},
+	}
+}
+
+// Median of values, which are sorted in place
+func median(values []float64) float64 {
+	slices.Sort(values)
+
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+
+	return values[mid]
+}
@@ -0,0 +1,136 @@
+package filter
+
+import (
+	"slices"
+	"testing"
+	"time"
+	"zone-finder/types"
+)
+
+func createHRData(startTime time.Time, hrValues []int) []types.HRDataPoint {
+	dataPoints := make([]types.HRDataPoint, len(hrValues))
+	for i, hr := range hrValues {
+		dataPoints[i] = types.HRDataPoint{
+			Timestamp: startTime.Add(time.Duration(i) * time.Second),
+			HeartRate: hr,
+		}
+	}
+	return dataPoints
+}
+
+func heartRates(dataPoints []types.HRDataPoint) []int {
+	hrValues := make([]int, len(dataPoints))
+	for i, dp := range dataPoints {
+		hrValues[i] = dp.HeartRate
+	}
+	return hrValues
+}
+
+// A walk at ~100 bpm where an optical sensor locked onto cadence for 5 seconds
+func createCadenceLock(baseTime time.Time) []types.HRDataPoint {
+	var hrValues []int
+	for i := 0; i < 60; i++ {
+		hrValues = append(hrValues, 100+i%3)
+	}
+	for i := 30; i < 35; i++ {
+		hrValues[i] = 190
+	}
+	return createHRData(baseTime, hrValues)
+}
+
+func TestStages(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		stage       Stage
+		data        []types.HRDataPoint
+		wantRemoved int
+	}{
+		{
+			name:        "range removes dropouts and impossible values",
+			stage:       Range(30, 240),
+			data:        createHRData(baseTime, []int{150, 0, 151, 255, 152}),
+			wantRemoved: 2,
+		},
+		{
+			name:        "rate of change removes a cadence lock",
+			stage:       RateOfChange(10),
+			data:        createCadenceLock(baseTime),
+			wantRemoved: 5,
+		},
+		{
+			name:        "rate of change keeps a gradual rise",
+			stage:       RateOfChange(10),
+			data:        createHRData(baseTime, []int{120, 123, 126, 130, 134, 138}),
+			wantRemoved: 0,
+		},
+		{
+			name:        "rate of change accepts a jump after a pause",
+			stage:       RateOfChange(10),
+			data:        append(createHRData(baseTime, []int{100, 100}), createHRData(baseTime.Add(time.Minute), []int{160, 160})...),
+			wantRemoved: 0,
+		},
+		{
+			name:        "hampel removes a spike",
+			stage:       Hampel(15, 3),
+			data:        createCadenceLock(baseTime),
+			wantRemoved: 5,
+		},
+		{
+			name:        "hampel keeps small changes in a steady effort",
+			stage:       Hampel(15, 3),
+			data:        createHRData(baseTime, []int{150, 150, 150, 151, 150, 150, 149, 150, 150}),
+			wantRemoved: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept := tt.stage.Apply(tt.data)
+
+			if removed := len(tt.data) - len(kept); removed != tt.wantRemoved {
+				t.Errorf("%s removed %d data points, want %d: kept %v", tt.stage.Name, removed, tt.wantRemoved, heartRates(kept))
+			}
+		})
+	}
+}
+
+func TestPipeline_Apply(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	data := createCadenceLock(baseTime)
+	data[10].HeartRate = 0
+	// out of order, as the pipeline sorts
+	data[0], data[1] = data[1], data[0]
+	original := slices.Clone(data)
+
+	filtered, results := Default().Apply(data)
+
+	wantResults := []Result{
+		{Stage: "range", Removed: 1},
+		{Stage: "rate", Removed: 5},
+		{Stage: "hampel", Removed: 0},
+	}
+	if !slices.Equal(results, wantResults) {
+		t.Errorf("Apply() results = %v, want %v", results, wantResults)
+	}
+
+	if got := Removed(results); got != 6 {
+		t.Errorf("Removed() = %d, want 6", got)
+	}
+
+	if len(filtered) != len(data)-6 {
+		t.Errorf("Apply() kept %d data points, want %d", len(filtered), len(data)-6)
+	}
+
+	for i := 1; i < len(filtered); i++ {
+		if filtered[i].Timestamp.Before(filtered[i-1].Timestamp) {
+			t.Fatal("Apply() should return data points sorted by timestamp")
+		}
+	}
+
+	if !slices.Equal(data, original) {
+		t.Error("Apply() should not modify its input")
+	}
+}
@@ -1,13 +1,18 @@
 package fit
 
 import (
+	"io"
+	"math"
 	"os"
 	"strings"
+	"time"
 	"zone-finder/types"
 
 	"github.com/muktihari/fit/decoder"
+	"github.com/muktihari/fit/profile/basetype"
 	"github.com/muktihari/fit/profile/mesgdef"
 	"github.com/muktihari/fit/profile/typedef"
+	"github.com/muktihari/fit/profile/untyped/mesgnum"
 	"github.com/muktihari/fit/proto"
 )
 
@@ -25,7 +30,11 @@ func ParseFIT(filepath string) (*FITData, error) {
 	}
 	defer fitFile.Close()
 
-	dec := decoder.New(fitFile)
+	return ParseFITReader(fitFile)
+}
+
+func ParseFITReader(r io.Reader) (*FITData, error) {
+	dec := decoder.New(r)
 	fit, err := dec.Decode()
 	if err != nil {
 		return nil, err
@@ -59,26 +68,179 @@ func isValidDeviceInfo(deviceInfo *mesgdef.DeviceInfo) bool {
 }
 
 func (fit *FITData) GetHRDataPoints() ([]types.HRDataPoint, error) {
-	var dataPoints []types.HRDataPoint
+	samples, err := fit.GetSamples()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.HRDataPoints(samples), nil
+}
+
+func (fit *FITData) GetSamples() ([]types.Sample, error) {
+	var samples []types.Sample
 
 	for _, msg := range fit.messages {
-		record := mesgdef.NewRecord(&msg)
-		if record == nil || record.HeartRate == missingHeartRate {
+		if msg.Num != mesgnum.Record {
 			continue
 		}
 
+		record := mesgdef.NewRecord(&msg)
+
 		// skip invalid timestamps
 		if record.Timestamp.IsZero() || record.Timestamp.Year() < 2000 {
 			continue
 		}
 
-		dataPoints = append(dataPoints, types.HRDataPoint{
-			Timestamp: record.Timestamp,
-			HeartRate: int(record.HeartRate),
-		})
+		samples = append(samples, newSample(record))
+	}
+
+	return samples, nil
+}
+
+func newSample(record *mesgdef.Record) types.Sample {
+	sample := types.Sample{
+		Timestamp: record.Timestamp,
+		Speed:     validFloat(record.EnhancedSpeedScaled(), record.SpeedScaled()),
+		Altitude:  validFloat(record.EnhancedAltitudeScaled(), record.AltitudeScaled()),
+		Distance:  validFloat(record.DistanceScaled()),
+	}
+
+	if record.HeartRate != missingHeartRate {
+		heartRate := int(record.HeartRate)
+		sample.HeartRate = &heartRate
+	}
+
+	if record.Power != basetype.Uint16Invalid {
+		power := int(record.Power)
+		sample.Power = &power
+	}
+
+	if record.Cadence != basetype.Uint8Invalid {
+		cadence := int(record.Cadence)
+		sample.Cadence = &cadence
+	}
+
+	if record.PositionLat != basetype.Sint32Invalid && record.PositionLong != basetype.Sint32Invalid {
+		sample.Position = &types.Position{
+			Latitude:  record.PositionLatDegrees(),
+			Longitude: record.PositionLongDegrees(),
+		}
+	}
+
+	return sample
+}
+
+// Returns the first valid value, scaled FIT values are NaN when invalid
+func validFloat(values ...float64) *float64 {
+	for _, value := range values {
+		if !math.IsNaN(value) {
+			return &value
+		}
+	}
+
+	return nil
+}
+
+// Sport of the first session, or of the first lap for files without sessions
+func (fit *FITData) GetSport() types.Sport {
+	sport := typedef.SportInvalid
+
+	for _, msg := range fit.messages {
+		if msg.Num == mesgnum.Session {
+			sport = mesgdef.NewSession(&msg).Sport
+			break
+		}
+
+		if msg.Num == mesgnum.Lap && sport == typedef.SportInvalid {
+			sport = mesgdef.NewLap(&msg).Sport
+		}
 	}
 
-	return dataPoints, nil
+	return types.ParseSport(sport.String())
+}
+
+// Laps recorded by the device, falling back to sessions for devices that
+// don't record laps
+func (fit *FITData) GetLaps() ([]types.Lap, error) {
+	var laps, sessions []types.Lap
+
+	for _, msg := range fit.messages {
+		switch msg.Num {
+		case mesgnum.Lap:
+			lap := mesgdef.NewLap(&msg)
+			laps = append(laps, lapSummary{
+				startTime:        lap.StartTime,
+				timestamp:        lap.Timestamp,
+				totalElapsedTime: lap.TotalElapsedTimeScaled(),
+				totalTimerTime:   lap.TotalTimerTimeScaled(),
+				avgHeartRate:     lap.AvgHeartRate,
+				maxHeartRate:     lap.MaxHeartRate,
+				trigger:          lap.LapTrigger.String(),
+			}.lap())
+		case mesgnum.Session:
+			session := mesgdef.NewSession(&msg)
+			sessions = append(sessions, lapSummary{
+				startTime:        session.StartTime,
+				timestamp:        session.Timestamp,
+				totalElapsedTime: session.TotalElapsedTimeScaled(),
+				totalTimerTime:   session.TotalTimerTimeScaled(),
+				avgHeartRate:     session.AvgHeartRate,
+				maxHeartRate:     session.MaxHeartRate,
+				trigger:          session.Trigger.String(),
+			}.lap())
+		}
+	}
+
+	if len(laps) == 0 {
+		return sessions, nil
+	}
+
+	return laps, nil
+}
+
+// Fields shared by FIT Lap and Session messages
+type lapSummary struct {
+	startTime        time.Time
+	timestamp        time.Time
+	totalElapsedTime float64
+	totalTimerTime   float64
+	avgHeartRate     uint8
+	maxHeartRate     uint8
+	trigger          string
+}
+
+func (s lapSummary) lap() types.Lap {
+	lap := types.Lap{
+		StartTime: s.startTime,
+		EndTime:   s.timestamp,
+	}
+
+	// some devices write the file creation time as the lap timestamp
+	if !lap.EndTime.After(lap.StartTime) && !math.IsNaN(s.totalElapsedTime) {
+		lap.EndTime = lap.StartTime.Add(seconds(s.totalElapsedTime))
+	}
+
+	if !math.IsNaN(s.totalTimerTime) {
+		lap.TotalTime = seconds(s.totalTimerTime)
+	}
+
+	if s.avgHeartRate != missingHeartRate {
+		lap.AvgHeartRate = int(s.avgHeartRate)
+	}
+
+	if s.maxHeartRate != missingHeartRate {
+		lap.MaxHeartRate = int(s.maxHeartRate)
+	}
+
+	if !strings.Contains(s.trigger, "Invalid") {
+		lap.Trigger = s.trigger
+	}
+
+	return lap
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Round(s * float64(time.Second)))
 }
 
 func (fit *FITData) GetDeviceName() string {
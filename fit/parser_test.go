@@ -1,7 +1,10 @@
 package fit
 
 import (
+	"os"
 	"testing"
+	"time"
+	"zone-finder/types"
 )
 
 func TestParseFIT(t *testing.T) {
@@ -58,6 +61,28 @@ func TestParseFIT(t *testing.T) {
 	}
 }
 
+func TestParseFITReader(t *testing.T) {
+	file, err := os.Open("testdata/treadmill_run_watch.fit")
+	if err != nil {
+		t.Fatalf("failed to open FIT file: %v", err)
+	}
+	defer file.Close()
+
+	fitData, err := ParseFITReader(file)
+	if err != nil {
+		t.Fatalf("ParseFITReader() error = %v", err)
+	}
+
+	dataPoints, err := fitData.GetHRDataPoints()
+	if err != nil {
+		t.Fatalf("GetHRDataPoints() error = %v", err)
+	}
+
+	if len(dataPoints) == 0 {
+		t.Error("expected heart rate data points, got none")
+	}
+}
+
 func TestGetHRDataPoints(t *testing.T) {
 	fitData, err := ParseFIT("testdata/treadmill_run_watch.fit")
 	if err != nil {
@@ -137,6 +162,147 @@ func TestGetHRDataPoints_MultipleFiles(t *testing.T) {
 	}
 }
 
+func TestGetSamples(t *testing.T) {
+	tests := []struct {
+		name         string
+		filepath     string
+		wantPosition bool
+	}{
+		{
+			name:         "treadmill run watch",
+			filepath:     "testdata/treadmill_run_watch.fit",
+			wantPosition: false,
+		},
+		{
+			name:         "outside run armband",
+			filepath:     "testdata/outside_run_armband.fit",
+			wantPosition: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fitData, err := ParseFIT(tt.filepath)
+			if err != nil {
+				t.Fatalf("ParseFIT() error = %v", err)
+			}
+
+			samples, err := fitData.GetSamples()
+			if err != nil {
+				t.Fatalf("GetSamples() error = %v", err)
+			}
+
+			if len(samples) == 0 {
+				t.Fatal("expected samples, got none")
+			}
+
+			sample := samples[len(samples)/2]
+			if sample.HeartRate == nil || sample.Speed == nil || sample.Distance == nil ||
+				sample.Cadence == nil || sample.Power == nil {
+				t.Errorf("sample %+v is missing recorded measurements", sample)
+			}
+
+			if (sample.Position != nil) != tt.wantPosition {
+				t.Errorf("sample Position = %v, want present %v", sample.Position, tt.wantPosition)
+			}
+
+			if sample.Position != nil && (sample.Position.Latitude < 36 || sample.Position.Latitude > 37) {
+				t.Errorf("sample Latitude = %v, want ~36.07", sample.Position.Latitude)
+			}
+
+			// GetHRDataPoints is a projection of the samples with a heart rate
+			dataPoints, _ := fitData.GetHRDataPoints()
+			withHR := 0
+			for _, s := range samples {
+				if s.HeartRate != nil {
+					withHR++
+				}
+			}
+			if len(dataPoints) != withHR {
+				t.Errorf("GetHRDataPoints() returned %d points, want %d", len(dataPoints), withHR)
+			}
+		})
+	}
+}
+
+func TestGetLaps(t *testing.T) {
+	tests := []struct {
+		name        string
+		filepath    string
+		wantLaps    int
+		wantFirst   string
+		wantTrigger string
+		wantAvgHR   int
+		wantMaxHR   int
+	}{
+		{
+			name:        "lap messages",
+			filepath:    "testdata/outside_run_armband.fit",
+			wantLaps:    9,
+			wantFirst:   "2025-04-26T15:15:28Z",
+			wantTrigger: "distance",
+			wantAvgHR:   121,
+			wantMaxHR:   133,
+		},
+		{
+			name:        "single lap ended by the session",
+			filepath:    "testdata/treadmill_run_watch.fit",
+			wantLaps:    1,
+			wantFirst:   "2025-10-28T18:42:48Z",
+			wantTrigger: "session_end",
+			wantAvgHR:   129,
+			wantMaxHR:   152,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fitData, err := ParseFIT(tt.filepath)
+			if err != nil {
+				t.Fatalf("ParseFIT() error = %v", err)
+			}
+
+			laps, err := fitData.GetLaps()
+			if err != nil {
+				t.Fatalf("GetLaps() error = %v", err)
+			}
+
+			if len(laps) != tt.wantLaps {
+				t.Fatalf("got %d laps, want %d", len(laps), tt.wantLaps)
+			}
+
+			first := laps[0]
+			wantStart, _ := time.Parse(time.RFC3339, tt.wantFirst)
+			if !first.StartTime.Equal(wantStart) {
+				t.Errorf("StartTime = %v, want %v", first.StartTime, wantStart)
+			}
+
+			if first.Trigger != tt.wantTrigger || first.AvgHeartRate != tt.wantAvgHR || first.MaxHeartRate != tt.wantMaxHR {
+				t.Errorf("lap = %+v, want trigger %s, avg %d, max %d", first, tt.wantTrigger, tt.wantAvgHR, tt.wantMaxHR)
+			}
+
+			for i, lap := range laps {
+				if !lap.EndTime.After(lap.StartTime) || lap.TotalTime <= 0 {
+					t.Errorf("lap %d has invalid timing: %+v", i+1, lap)
+				}
+			}
+		})
+	}
+}
+
+func TestGetSport(t *testing.T) {
+	for _, filepath := range []string{"testdata/treadmill_run_watch.fit", "testdata/outside_run_armband.fit"} {
+		fitData, err := ParseFIT(filepath)
+		if err != nil {
+			t.Fatalf("ParseFIT() error = %v", err)
+		}
+
+		if got := fitData.GetSport(); got != types.SportRunning {
+			t.Errorf("%s: GetSport() = %q, want %q", filepath, got, types.SportRunning)
+		}
+	}
+}
+
 func TestGetDeviceInfo(t *testing.T) {
 	fitData, err := ParseFIT("testdata/treadmill_run_watch.fit")
 	if err != nil {
@@ -2,4 +2,4 @@ module zone-finder
 
 go 1.25.0
 
-require github.com/muktihari/fit v0.25.1 // indirect
+require github.com/muktihari/fit v0.25.1
@@ -0,0 +1,135 @@
+package gpx
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"time"
+	"zone-finder/types"
+)
+
+type GPXData struct {
+	Creator string  `xml:"creator,attr"`
+	Tracks  []track `xml:"trk"`
+}
+
+type track struct {
+	Name     string    `xml:"name"`
+	Type     string    `xml:"type"`
+	Segments []segment `xml:"trkseg"`
+}
+
+type segment struct {
+	Trackpoints []trackpoint `xml:"trkpt"`
+}
+
+type trackpoint struct {
+	Latitude   float64    `xml:"lat,attr"`
+	Longitude  float64    `xml:"lon,attr"`
+	Elevation  *float64   `xml:"ele"`
+	Time       time.Time  `xml:"time"`
+	Extensions extensions `xml:"extensions"`
+}
+
+type extensions struct {
+	TrackPointExtension trackPointExtension `xml:"TrackPointExtension"`
+}
+
+// Garmin TrackPointExtension (gpxtpx) as written by most watches and apps
+type trackPointExtension struct {
+	HeartRate int  `xml:"hr"`
+	Cadence   *int `xml:"cad"`
+}
+
+func ParseGPX(filepath string) (*GPXData, error) {
+	gpxFile, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+	defer gpxFile.Close()
+
+	return ParseGPXReader(gpxFile)
+}
+
+func ParseGPXReader(r io.Reader) (*GPXData, error) {
+	var gpxData GPXData
+
+	if err := xml.NewDecoder(r).Decode(&gpxData); err != nil {
+		return &GPXData{}, err
+	}
+
+	return &gpxData, nil
+}
+
+func (gpx *GPXData) GetDeviceName() string {
+	if gpx.Creator == "" {
+		return "Unknown"
+	}
+
+	return gpx.Creator
+}
+
+// GPX has no notion of a product ID
+func (gpx *GPXData) GetProductID() int {
+	return 0
+}
+
+// Sport from the first track's type, as written by Garmin and Strava
+func (gpx *GPXData) GetSport() types.Sport {
+	if len(gpx.Tracks) == 0 {
+		return ""
+	}
+
+	return types.ParseSport(gpx.Tracks[0].Type)
+}
+
+// GPX has no laps, track segments only mark recording pauses
+func (gpx *GPXData) GetLaps() ([]types.Lap, error) {
+	return nil, nil
+}
+
+func (gpx *GPXData) GetHRDataPoints() ([]types.HRDataPoint, error) {
+	samples, err := gpx.GetSamples()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.HRDataPoints(samples), nil
+}
+
+func (gpx *GPXData) GetSamples() ([]types.Sample, error) {
+	var samples []types.Sample
+
+	for _, track := range gpx.Tracks {
+		for _, segment := range track.Segments {
+			for _, trackpoint := range segment.Trackpoints {
+				if trackpoint.Time.IsZero() {
+					continue
+				}
+
+				samples = append(samples, trackpoint.sample())
+			}
+		}
+	}
+	return samples, nil
+}
+
+func (tp trackpoint) sample() types.Sample {
+	extension := tp.Extensions.TrackPointExtension
+
+	sample := types.Sample{
+		Timestamp: tp.Time,
+		Altitude:  tp.Elevation,
+		Cadence:   extension.Cadence,
+		Position: &types.Position{
+			Latitude:  tp.Latitude,
+			Longitude: tp.Longitude,
+		},
+	}
+
+	if heartRate := extension.HeartRate; heartRate != 0 {
+		sample.HeartRate = &heartRate
+	}
+
+	return sample
+}
@@ -0,0 +1,190 @@
+package gpx
+
+import (
+	"os"
+	"testing"
+	"time"
+	"zone-finder/types"
+)
+
+func TestParseGPX(t *testing.T) {
+	tests := []struct {
+		name       string
+		filepath   string
+		wantErr    bool
+		wantDevice string
+		wantTracks int
+	}{
+		{
+			name:       "valid armband file",
+			filepath:   "testdata/outside_run_armband.gpx",
+			wantErr:    false,
+			wantDevice: "Forerunner 265",
+			wantTracks: 1,
+		},
+		{
+			name:     "non-existent file",
+			filepath: "testdata/does_not_exist.gpx",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gpx, err := ParseGPX(tt.filepath)
+
+			// Check error expectation
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseGPX() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			// If we expected an error, we're done
+			if tt.wantErr {
+				return
+			}
+
+			if got := len(gpx.Tracks); got != tt.wantTracks {
+				t.Errorf("Tracks = %v, want %v", got, tt.wantTracks)
+			}
+
+			if got := gpx.GetDeviceName(); got != tt.wantDevice {
+				t.Errorf("DeviceName = %v, want %v", got, tt.wantDevice)
+			}
+
+			if got := gpx.GetProductID(); got != 0 {
+				t.Errorf("ProductID = %v, want 0", got)
+			}
+		})
+	}
+}
+
+func TestParseGPX_ValidatesFileFormat(t *testing.T) {
+	// Try parsing a FIT file as GPX - should fail gracefully
+	_, err := ParseGPX("../fit/testdata/treadmill_run_watch.fit")
+	if err == nil {
+		t.Error("Expected error when parsing FIT file as GPX")
+	}
+}
+
+func TestParseGPXReader(t *testing.T) {
+	file, err := os.Open("testdata/outside_run_armband.gpx")
+	if err != nil {
+		t.Fatalf("failed to open GPX file: %v", err)
+	}
+	defer file.Close()
+
+	gpx, err := ParseGPXReader(file)
+	if err != nil {
+		t.Fatalf("ParseGPXReader() error = %v", err)
+	}
+
+	dataPoints, err := gpx.GetHRDataPoints()
+	if err != nil {
+		t.Fatalf("GetHRDataPoints() error = %v", err)
+	}
+
+	if len(dataPoints) == 0 {
+		t.Error("expected heart rate data points, got none")
+	}
+}
+
+func TestGetHRDataPoints(t *testing.T) {
+	gpx, err := ParseGPX("testdata/outside_run_armband.gpx")
+	if err != nil {
+		t.Fatalf("Failed to parse GPX file: %v", err)
+	}
+
+	dataPoints, err := gpx.GetHRDataPoints()
+	if err != nil {
+		t.Fatalf("GetHRDataPoints() error = %v", err)
+	}
+
+	if len(dataPoints) == 0 {
+		t.Fatal("Expected heart rate data points, got none")
+	}
+
+	// First HR reading appears at 2025-04-26T15:15:28Z with value 67
+	expectedTime, _ := time.Parse(time.RFC3339, "2025-04-26T15:15:28Z")
+	if first := dataPoints[0]; !first.Timestamp.Equal(expectedTime) || first.HeartRate != 67 {
+		t.Errorf("First data point = %+v, want HR 67 at %v", first, expectedTime)
+	}
+
+	// Check timestamps are in order
+	for i := 1; i < len(dataPoints); i++ {
+		if dataPoints[i].Timestamp.Before(dataPoints[i-1].Timestamp) {
+			t.Errorf("Timestamps out of order at index %d", i)
+		}
+	}
+}
+
+func TestGetSamples(t *testing.T) {
+	gpx, err := ParseGPX("testdata/outside_run_armband.gpx")
+	if err != nil {
+		t.Fatalf("Failed to parse GPX file: %v", err)
+	}
+
+	samples, err := gpx.GetSamples()
+	if err != nil {
+		t.Fatalf("GetSamples() error = %v", err)
+	}
+
+	if len(samples) == 0 {
+		t.Fatal("Expected samples, got none")
+	}
+
+	first := samples[0]
+	if first.HeartRate == nil || *first.HeartRate != 67 {
+		t.Errorf("HeartRate = %v, want 67", first.HeartRate)
+	}
+
+	if first.Cadence == nil || *first.Cadence != 59 {
+		t.Errorf("Cadence = %v, want 59", first.Cadence)
+	}
+
+	if first.Altitude == nil || *first.Altitude != 125.80000305175781 {
+		t.Errorf("Altitude = %v, want 125.8", first.Altitude)
+	}
+
+	if first.Position == nil || first.Position.Latitude != 36.06641253456473 || first.Position.Longitude != -78.94825271330774 {
+		t.Errorf("Position = %+v, want 36.0664, -78.9483", first.Position)
+	}
+
+	// GPX has no speed or power in the TrackPointExtension
+	if first.Speed != nil || first.Power != nil {
+		t.Errorf("Speed = %v, Power = %v, want nil", first.Speed, first.Power)
+	}
+}
+
+func TestGetSport(t *testing.T) {
+	gpx, err := ParseGPX("testdata/outside_run_armband.gpx")
+	if err != nil {
+		t.Fatalf("Failed to parse GPX file: %v", err)
+	}
+
+	if got := gpx.GetSport(); got != types.SportRunning {
+		t.Errorf("GetSport() = %q, want %q", got, types.SportRunning)
+	}
+
+	if got := (&GPXData{}).GetSport(); got != "" {
+		t.Errorf("GetSport() without tracks = %q, want \"\"", got)
+	}
+}
+
+func TestGetDeviceName_MissingCreator(t *testing.T) {
+	gpx := &GPXData{}
+
+	if got := gpx.GetDeviceName(); got != "Unknown" {
+		t.Errorf("DeviceName = %v, want Unknown", got)
+	}
+}
+
+// Benchmark parsing performance
+func BenchmarkParseGPX(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, err := ParseGPX("testdata/outside_run_armband.gpx")
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Package load calculates heart-rate-based training load: hrTSS, Banister
+// TRIMP and Edwards TRIMP
+package load
+
+import (
+	"math"
+	"slices"
+	"time"
+	"zone-finder/types"
+	"zone-finder/zones"
+)
+
+// How Banister TRIMP weights a fraction of heart rate reserve HRr:
+// Coefficient × e^(Exponent × HRr)
+type BanisterWeighting struct {
+	Coefficient float64
+	Exponent    float64
+}
+
+// Banister's weightings, fitted to the blood lactate of men and women
+var (
+	BanisterMale   = BanisterWeighting{Coefficient: 0.64, Exponent: 1.92}
+	BanisterFemale = BanisterWeighting{Coefficient: 0.86, Exponent: 1.67}
+)
+
+type Options struct {
+	// zones.DefaultGapPolicy's threshold when zero. Only the threshold is
+	// used
+	Gaps zones.GapPolicy
+	// BanisterMale when zero
+	Banister BanisterWeighting
+	// Used for Banister TRIMP when the zones don't know them, e.g. LTHR
+	// zones
+	RestingHR int
+	MaxHR     int
+}
+
+// Training load of a workout. A metric is 0 when the zones and options don't
+// give the heart rates it needs
+type Load struct {
+	// Needs LTHR
+	HRTSS float64
+	// Needs resting and max HR
+	BanisterTRIMP float64
+	// Needs max HR
+	EdwardsTRIMP float64
+}
+
+// Calculate hrTSS, Banister TRIMP and Edwards TRIMP from a workout and the
+// zones calculated for it
+func Calculate(dataPoints []types.HRDataPoint, hrZones zones.HeartRateZones, opts Options) Load {
+	restingHR := hrZones.RestingHR
+	if restingHR == 0 {
+		restingHR = opts.RestingHR
+	}
+	maxHR := hrZones.MaxHR
+	if maxHR == 0 {
+		maxHR = opts.MaxHR
+	}
+
+	var load Load
+	if hrZones.LTHR > 0 {
+		load.HRTSS = HRTSS(dataPoints, hrZones.LTHR, opts.Gaps)
+	}
+	if restingHR > 0 && maxHR > restingHR {
+		load.BanisterTRIMP = BanisterTRIMP(dataPoints, restingHR, maxHR, opts.Banister, opts.Gaps)
+	}
+	if maxHR > 0 {
+		load.EdwardsTRIMP = EdwardsTRIMP(dataPoints, maxHR, opts.Gaps)
+	}
+
+	return load
+}
+
+// An approximation of TrainingPeaks' heart rate Training Stress Score: each
+// hour scores 100 × (HR / LTHR)², so an hour at LTHR scores 100. TrainingPeaks
+// normalises TRIMP instead, so their scores differ away from threshold
+func HRTSS(dataPoints []types.HRDataPoint, lthr int, gaps zones.GapPolicy) float64 {
+	var tss float64
+	eachInterval(dataPoints, gaps, func(heartRate int, interval time.Duration) {
+		intensity := float64(heartRate) / float64(lthr)
+		tss += interval.Hours() * intensity * intensity * 100
+	})
+
+	return tss
+}
+
+// Banister's training impulse: each minute scores HRr × the weighting, where
+// HRr is the fraction of heart rate reserve. weighting is BanisterMale when
+// zero
+func BanisterTRIMP(dataPoints []types.HRDataPoint, restingHR, maxHR int, weighting BanisterWeighting, gaps zones.GapPolicy) float64 {
+	if weighting == (BanisterWeighting{}) {
+		weighting = BanisterMale
+	}
+
+	var trimp float64
+	eachInterval(dataPoints, gaps, func(heartRate int, interval time.Duration) {
+		reserve := max(float64(heartRate-restingHR)/float64(maxHR-restingHR), 0)
+		trimp += interval.Minutes() * reserve * weighting.Coefficient * math.Exp(weighting.Exponent*reserve)
+	})
+
+	return trimp
+}
+
+// Edwards' zone-weighted training impulse: each minute scores 1-5 in the
+// bands of 50-60% up to 90-100% of max HR, whatever zones the workout is
+// analyzed with. Time below 50% scores nothing
+func EdwardsTRIMP(dataPoints []types.HRDataPoint, maxHR int, gaps zones.GapPolicy) float64 {
+	var trimp float64
+	for _, zoneTime := range zones.CalculateTimeInZones(dataPoints, zones.CalculateMaxHRZones(maxHR), gaps).Zones {
+		trimp += zoneTime.Duration.Minutes() * float64(zoneTime.Zone.Number)
+	}
+
+	return trimp
+}
+
+// Call f with each sample's heart rate and the time it counts for: until the
+// next sample, and at most the gap threshold, as in zones.CalculateTimeInZones
+func eachInterval(dataPoints []types.HRDataPoint, gaps zones.GapPolicy, f func(heartRate int, interval time.Duration)) {
+	gaps = gaps.WithDefaults()
+
+	slices.SortFunc(dataPoints, func(a, b types.HRDataPoint) int { return a.Timestamp.Compare(b.Timestamp) })
+
+	for i := 0; i+1 < len(dataPoints); i++ {
+		f(dataPoints[i].HeartRate, min(dataPoints[i+1].Timestamp.Sub(dataPoints[i].Timestamp), gaps.Threshold))
+	}
+}
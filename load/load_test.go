@@ -0,0 +1,162 @@
+package load
+
+import (
+	"math"
+	"testing"
+	"time"
+	"zone-finder/types"
+	"zone-finder/zones"
+)
+
+// Constant heart rate for a number of seconds, recorded every second
+func createConstantHR(startTime time.Time, hr int, seconds int) []types.HRDataPoint {
+	dataPoints := make([]types.HRDataPoint, seconds)
+	for i := range dataPoints {
+		dataPoints[i] = types.HRDataPoint{
+			Timestamp: startTime.Add(time.Duration(i) * time.Second),
+			HeartRate: hr,
+		}
+	}
+	return dataPoints
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 0.01
+}
+
+func TestHRTSS(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		data []types.HRDataPoint
+		want float64
+	}{
+		{
+			name: "an hour at LTHR",
+			data: createConstantHR(baseTime, 170, 60*60+1),
+			want: 100,
+		},
+		{
+			name: "half an hour at LTHR",
+			data: createConstantHR(baseTime, 170, 30*60+1),
+			want: 50,
+		},
+		{
+			name: "an hour at 90% of LTHR",
+			data: createConstantHR(baseTime, 153, 60*60+1),
+			want: 81,
+		},
+		{
+			name: "a pause isn't counted",
+			data: append(
+				createConstantHR(baseTime, 170, 30*60),
+				createConstantHR(baseTime.Add(time.Hour), 170, 30*60+1)...,
+			),
+			// the sample before the pause counts for the 30s gap threshold
+			want: 100 + 30.0/3600*100 - 1.0/3600*100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HRTSS(tt.data, 170, zones.GapPolicy{}); !approxEqual(got, tt.want) {
+				t.Errorf("HRTSS() = %.3f, want %.3f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBanisterTRIMP(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	// an hour at half of heart rate reserve
+	data := createConstantHR(baseTime, 120, 60*60+1)
+
+	tests := []struct {
+		name      string
+		weighting BanisterWeighting
+		want      float64
+	}{
+		{name: "men by default", want: 60 * 0.5 * 0.64 * math.Exp(1.92*0.5)},
+		{name: "women", weighting: BanisterFemale, want: 60 * 0.5 * 0.86 * math.Exp(1.67*0.5)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BanisterTRIMP(data, 50, 190, tt.weighting, zones.GapPolicy{}); !approxEqual(got, tt.want) {
+				t.Errorf("BanisterTRIMP() = %.3f, want %.3f", got, tt.want)
+			}
+		})
+	}
+
+	if got := BanisterTRIMP(createConstantHR(baseTime, 40, 61), 50, 190, BanisterWeighting{}, zones.GapPolicy{}); got != 0 {
+		t.Errorf("BanisterTRIMP() = %.3f below resting HR, want 0", got)
+	}
+}
+
+func TestEdwardsTRIMP(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	// bands from 100, 120, 140, 160 and 180 bpm
+	var data []types.HRDataPoint
+	data = append(data, createConstantHR(baseTime, 90, 10*60)...)                        // below 50%
+	data = append(data, createConstantHR(baseTime.Add(10*time.Minute), 130, 20*60)...)   // 60-70%
+	data = append(data, createConstantHR(baseTime.Add(30*time.Minute), 185, 10*60+1)...) // 90-100%
+
+	if got, want := EdwardsTRIMP(data, 200, zones.GapPolicy{}), 20.0*2+10*5; !approxEqual(got, want) {
+		t.Errorf("EdwardsTRIMP() = %.3f, want %.3f", got, want)
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	data := createConstantHR(baseTime, 170, 60*60+1)
+
+	tests := []struct {
+		name         string
+		zones        zones.HeartRateZones
+		opts         Options
+		wantHRTSS    bool
+		wantBanister bool
+		wantEdwards  bool
+	}{
+		{
+			name:      "lthr zones",
+			zones:     zones.CalculateZones(170),
+			wantHRTSS: true,
+		},
+		{
+			name:         "lthr zones with resting and max HR from options",
+			zones:        zones.CalculateZones(170),
+			opts:         Options{RestingHR: 50, MaxHR: 190},
+			wantHRTSS:    true,
+			wantBanister: true,
+			wantEdwards:  true,
+		},
+		{
+			name:         "heart rate reserve zones",
+			zones:        zones.CalculateHeartRateReserveZones(50, 190),
+			wantBanister: true,
+			wantEdwards:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			load := Calculate(data, tt.zones, tt.opts)
+
+			if (load.HRTSS != 0) != tt.wantHRTSS {
+				t.Errorf("HRTSS = %.1f, want it calculated %v", load.HRTSS, tt.wantHRTSS)
+			}
+
+			if (load.BanisterTRIMP != 0) != tt.wantBanister {
+				t.Errorf("BanisterTRIMP = %.1f, want it calculated %v", load.BanisterTRIMP, tt.wantBanister)
+			}
+
+			if (load.EdwardsTRIMP != 0) != tt.wantEdwards {
+				t.Errorf("EdwardsTRIMP = %.1f, want it calculated %v", load.EdwardsTRIMP, tt.wantEdwards)
+			}
+		})
+	}
+}
@@ -2,16 +2,32 @@ package tcx
 
 import (
 	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
 	"os"
+	"strings"
 	"time"
 	"zone-finder/types"
 )
 
 type TCXData struct {
 	Activities activities `xml:"Activities"`
+	// index into allActivities() that the Get methods report on
+	selected int
 }
 
 type activities struct {
+	Activity          []activity          `xml:"Activity"`
+	MultiSportSession []multiSportSession `xml:"MultiSportSession"`
+}
+
+type multiSportSession struct {
+	FirstSport sportLeg   `xml:"FirstSport"`
+	NextSport  []sportLeg `xml:"NextSport"`
+}
+
+type sportLeg struct {
 	Activity activity `xml:"Activity"`
 }
 
@@ -23,8 +39,12 @@ type activity struct {
 }
 
 type lap struct {
-	StartTime string  `xml:"StartTime,attr"`
-	Tracks    []track `xml:"Track"`
+	StartTime           time.Time    `xml:"StartTime,attr"`
+	TotalTimeSeconds    float64      `xml:"TotalTimeSeconds"`
+	AverageHeartRateBpm heartRateBpm `xml:"AverageHeartRateBpm"`
+	MaximumHeartRateBpm heartRateBpm `xml:"MaximumHeartRateBpm"`
+	TriggerMethod       string       `xml:"TriggerMethod"`
+	Tracks              []track      `xml:"Track"`
 }
 
 type track struct {
@@ -32,63 +52,201 @@ type track struct {
 }
 
 type trackpoint struct {
-	Time         time.Time    `xml:"Time"`
-	HeartRateBpm heartRateBpm `xml:"HeartRateBpm"`
+	Time           time.Time            `xml:"Time"`
+	Position       *position            `xml:"Position"`
+	AltitudeMeters *float64             `xml:"AltitudeMeters"`
+	DistanceMeters *float64             `xml:"DistanceMeters"`
+	HeartRateBpm   heartRateBpm         `xml:"HeartRateBpm"`
+	Cadence        *int                 `xml:"Cadence"`
+	Extensions     trackpointExtensions `xml:"Extensions"`
+}
+
+type position struct {
+	LatitudeDegrees  float64 `xml:"LatitudeDegrees"`
+	LongitudeDegrees float64 `xml:"LongitudeDegrees"`
 }
 
 type heartRateBpm struct {
 	Value int `xml:"Value"`
 }
 
+// Garmin ActivityExtension (ns3:TPX) values
+type trackpointExtensions struct {
+	TPX struct {
+		Speed      *float64 `xml:"Speed"`
+		RunCadence *int     `xml:"RunCadence"`
+		Watts      *int     `xml:"Watts"`
+	} `xml:"TPX"`
+}
+
 type creator struct {
 	Name      string `xml:"Name"`
 	ProductId int    `xml:"ProductID"`
 }
 
 func ParseTCX(filepath string) (*TCXData, error) {
-	var tcxData TCXData
-
 	tcxFile, err := os.Open(filepath)
 	if err != nil {
 		return nil, err
 	}
 	defer tcxFile.Close()
 
-	if err := xml.NewDecoder(tcxFile).Decode(&tcxData); err != nil {
+	return ParseTCXReader(tcxFile)
+}
+
+func ParseTCXReader(r io.Reader) (*TCXData, error) {
+	var tcxData TCXData
+
+	if err := xml.NewDecoder(r).Decode(&tcxData); err != nil {
 		return &TCXData{}, err
 	}
 
 	return &tcxData, nil
 }
 
+// All activities in the file: standalone activities followed by the legs
+// of any multisport sessions
+func (tcx *TCXData) allActivities() []activity {
+	all := append([]activity(nil), tcx.Activities.Activity...)
+
+	for _, session := range tcx.Activities.MultiSportSession {
+		all = append(all, session.FirstSport.Activity)
+		for _, leg := range session.NextSport {
+			all = append(all, leg.Activity)
+		}
+	}
+
+	return all
+}
+
+func (tcx *TCXData) activity() activity {
+	all := tcx.allActivities()
+	if tcx.selected >= len(all) {
+		return activity{}
+	}
+
+	return all[tcx.selected]
+}
+
+func (tcx *TCXData) GetActivities() []types.Activity {
+	var summaries []types.Activity
+
+	for _, activity := range tcx.allActivities() {
+		summary := types.Activity{
+			Id:    activity.Id,
+			Sport: types.ParseSport(activity.Sport),
+		}
+		if len(activity.Laps) > 0 {
+			summary.StartTime = activity.Laps[0].StartTime
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	return summaries
+}
+
+// Select the activity, by index into GetActivities, that the other methods
+// report on. The first activity is selected by default
+func (tcx *TCXData) SelectActivity(index int) error {
+	if count := len(tcx.allActivities()); index < 0 || index >= count {
+		return fmt.Errorf("activity %d out of range: file contains %d activities", index+1, count)
+	}
+
+	tcx.selected = index
+	return nil
+}
+
 func (tcx *TCXData) GetDeviceName() string {
-	return tcx.Activities.Activity.Creator.Name
+	return tcx.activity().Creator.Name
 }
 
 func (tcx *TCXData) GetProductID() int {
-	return tcx.Activities.Activity.Creator.ProductId
+	return tcx.activity().Creator.ProductId
+}
+
+func (tcx *TCXData) GetSport() types.Sport {
+	return types.ParseSport(tcx.activity().Sport)
+}
+
+func (tcx *TCXData) GetLaps() ([]types.Lap, error) {
+	var laps []types.Lap
+
+	for _, lap := range tcx.activity().Laps {
+		totalTime := time.Duration(math.Round(lap.TotalTimeSeconds * float64(time.Second)))
+
+		laps = append(laps, types.Lap{
+			StartTime:    lap.StartTime,
+			EndTime:      lap.endTime(totalTime),
+			TotalTime:    totalTime,
+			AvgHeartRate: lap.AverageHeartRateBpm.Value,
+			MaxHeartRate: lap.MaximumHeartRateBpm.Value,
+			Trigger:      strings.ToLower(lap.TriggerMethod),
+		})
+	}
+	return laps, nil
+}
+
+// The last trackpoint of the lap, or the start time plus the timer time for
+// laps without trackpoints
+func (l lap) endTime(totalTime time.Duration) time.Time {
+	endTime := l.StartTime.Add(totalTime)
+
+	for _, track := range l.Tracks {
+		if n := len(track.Trackpoints); n > 0 && track.Trackpoints[n-1].Time.After(l.StartTime) {
+			endTime = track.Trackpoints[n-1].Time
+		}
+	}
+
+	return endTime
 }
 
 func (tcx *TCXData) GetHRDataPoints() ([]types.HRDataPoint, error) {
-	var dataPoints []types.HRDataPoint
+	samples, err := tcx.GetSamples()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.HRDataPoints(samples), nil
+}
 
-	for _, lap := range tcx.Activities.Activity.Laps {
+func (tcx *TCXData) GetSamples() ([]types.Sample, error) {
+	var samples []types.Sample
+
+	for _, lap := range tcx.activity().Laps {
 		for _, track := range lap.Tracks {
 			for _, trackpoint := range track.Trackpoints {
-				heartRate := trackpoint.HeartRateBpm.Value
-
-				if heartRate == 0 {
-					continue
-				}
-
-				dataPoints = append(dataPoints,
-					types.HRDataPoint{
-						Timestamp: trackpoint.Time,
-						HeartRate: trackpoint.HeartRateBpm.Value,
-					},
-				)
+				samples = append(samples, trackpoint.sample())
 			}
 		}
 	}
-	return dataPoints, nil
+	return samples, nil
+}
+
+func (tp trackpoint) sample() types.Sample {
+	sample := types.Sample{
+		Timestamp: tp.Time,
+		Altitude:  tp.AltitudeMeters,
+		Distance:  tp.DistanceMeters,
+		Speed:     tp.Extensions.TPX.Speed,
+		Power:     tp.Extensions.TPX.Watts,
+		Cadence:   tp.Cadence,
+	}
+
+	if heartRate := tp.HeartRateBpm.Value; heartRate != 0 {
+		sample.HeartRate = &heartRate
+	}
+
+	if tp.Extensions.TPX.RunCadence != nil {
+		sample.Cadence = tp.Extensions.TPX.RunCadence
+	}
+
+	if tp.Position != nil {
+		sample.Position = &types.Position{
+			Latitude:  tp.Position.LatitudeDegrees,
+			Longitude: tp.Position.LongitudeDegrees,
+		}
+	}
+
+	return sample
 }
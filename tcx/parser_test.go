@@ -1,8 +1,11 @@
 package tcx
 
 import (
+	"os"
+	"strings"
 	"testing"
 	"time"
+	"zone-finder/types"
 )
 
 func TestParseTCX(t *testing.T) {
@@ -45,7 +48,7 @@ func TestParseTCX(t *testing.T) {
 			}
 
 			// Validate sport
-			if got := tcx.Activities.Activity.Sport; got != tt.wantSport {
+			if got := tcx.Activities.Activity[0].Sport; got != tt.wantSport {
 				t.Errorf("Sport = %v, want %v", got, tt.wantSport)
 			}
 
@@ -62,6 +65,28 @@ func TestParseTCX(t *testing.T) {
 	}
 }
 
+func TestParseTCXReader(t *testing.T) {
+	file, err := os.Open("testdata/treadmill_run_watch.tcx")
+	if err != nil {
+		t.Fatalf("failed to open TCX file: %v", err)
+	}
+	defer file.Close()
+
+	tcx, err := ParseTCXReader(file)
+	if err != nil {
+		t.Fatalf("ParseTCXReader() error = %v", err)
+	}
+
+	dataPoints, err := tcx.GetHRDataPoints()
+	if err != nil {
+		t.Fatalf("GetHRDataPoints() error = %v", err)
+	}
+
+	if len(dataPoints) == 0 {
+		t.Error("expected heart rate data points, got none")
+	}
+}
+
 func TestGetHRDataPoints(t *testing.T) {
 	tcx, err := ParseTCX("testdata/treadmill_run_watch.tcx")
 	if err != nil {
@@ -134,6 +159,231 @@ func TestGetHRDataPoints_Specific(t *testing.T) {
 	}
 }
 
+func TestGetSamples(t *testing.T) {
+	tcx, err := ParseTCX("testdata/outside_run_armband.tcx")
+	if err != nil {
+		t.Fatalf("Failed to parse TCX file: %v", err)
+	}
+
+	samples, err := tcx.GetSamples()
+	if err != nil {
+		t.Fatalf("GetSamples() error = %v", err)
+	}
+
+	if len(samples) == 0 {
+		t.Fatal("Expected samples, got none")
+	}
+
+	// First trackpoint of the file
+	first := samples[0]
+	checks := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{name: "heart rate", got: derefInt(first.HeartRate), want: 67},
+		{name: "cadence", got: derefInt(first.Cadence), want: 59},
+		{name: "power", got: derefInt(first.Power), want: 129},
+		{name: "speed", got: derefFloat(first.Speed), want: 1.4459999799728394},
+		{name: "altitude", got: derefFloat(first.Altitude), want: 125.80000305175781},
+		{name: "distance", got: derefFloat(first.Distance), want: 1.8700000047683716},
+		{name: "latitude", got: first.Position.Latitude, want: 36.06641253456473},
+		{name: "longitude", got: first.Position.Longitude, want: -78.94825271330774},
+	}
+
+	for _, tt := range checks {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func derefInt(v *int) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func derefFloat(v *float64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func TestGetLaps(t *testing.T) {
+	tcx, err := ParseTCX("testdata/outside_run_armband.tcx")
+	if err != nil {
+		t.Fatalf("Failed to parse TCX file: %v", err)
+	}
+
+	laps, err := tcx.GetLaps()
+	if err != nil {
+		t.Fatalf("GetLaps() error = %v", err)
+	}
+
+	if len(laps) != 9 {
+		t.Fatalf("Got %d laps, want 9", len(laps))
+	}
+
+	first := laps[0]
+	wantStart, _ := time.Parse(time.RFC3339, "2025-04-26T15:15:28.000Z")
+	if !first.StartTime.Equal(wantStart) {
+		t.Errorf("StartTime = %v, want %v", first.StartTime, wantStart)
+	}
+
+	if want := 541478 * time.Millisecond; first.TotalTime != want {
+		t.Errorf("TotalTime = %v, want %v", first.TotalTime, want)
+	}
+
+	if first.AvgHeartRate != 121 || first.MaxHeartRate != 133 {
+		t.Errorf("AvgHeartRate = %d, MaxHeartRate = %d, want 121 and 133", first.AvgHeartRate, first.MaxHeartRate)
+	}
+
+	if first.Trigger != "manual" {
+		t.Errorf("Trigger = %q, want manual", first.Trigger)
+	}
+
+	for i := 1; i < len(laps); i++ {
+		if laps[i].StartTime.Before(laps[i-1].EndTime) {
+			t.Errorf("lap %d starts before lap %d ends", i+1, i)
+		}
+	}
+}
+
+func TestGetSport(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     types.Sport
+	}{
+		{
+			name:     "running",
+			contents: `<TrainingCenterDatabase><Activities><Activity Sport="Running"></Activity></Activities></TrainingCenterDatabase>`,
+			want:     types.SportRunning,
+		},
+		{
+			name:     "biking",
+			contents: `<TrainingCenterDatabase><Activities><Activity Sport="Biking"></Activity></Activities></TrainingCenterDatabase>`,
+			want:     types.SportCycling,
+		},
+		{
+			name:     "other",
+			contents: `<TrainingCenterDatabase><Activities><Activity Sport="Other"></Activity></Activities></TrainingCenterDatabase>`,
+			want:     "",
+		},
+		{
+			name:     "missing",
+			contents: `<TrainingCenterDatabase><Activities><Activity></Activity></Activities></TrainingCenterDatabase>`,
+			want:     "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tcx, err := ParseTCXReader(strings.NewReader(tt.contents))
+			if err != nil {
+				t.Fatalf("ParseTCXReader() error = %v", err)
+			}
+
+			if got := tcx.GetSport(); got != tt.want {
+				t.Errorf("GetSport() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+const multiActivityTCX = `<TrainingCenterDatabase>
+  <Activities>
+    <Activity Sport="Running">
+      <Id>2025-05-01T07:00:00Z</Id>
+      <Lap StartTime="2025-05-01T07:00:00Z"><Track>
+        <Trackpoint><Time>2025-05-01T07:00:00Z</Time><HeartRateBpm><Value>140</Value></HeartRateBpm></Trackpoint>
+      </Track></Lap>
+    </Activity>
+    <Activity Sport="Biking">
+      <Id>2025-05-02T07:00:00Z</Id>
+      <Lap StartTime="2025-05-02T07:00:00Z"><Track>
+        <Trackpoint><Time>2025-05-02T07:00:00Z</Time><HeartRateBpm><Value>130</Value></HeartRateBpm></Trackpoint>
+      </Track></Lap>
+    </Activity>
+    <MultiSportSession>
+      <Id>2025-05-03T07:00:00Z</Id>
+      <FirstSport><Activity Sport="Biking"><Id>2025-05-03T07:00:00Z</Id></Activity></FirstSport>
+      <NextSport><Activity Sport="Running"><Id>2025-05-03T08:00:00Z</Id></Activity></NextSport>
+    </MultiSportSession>
+  </Activities>
+</TrainingCenterDatabase>`
+
+func TestGetActivities(t *testing.T) {
+	tcx, err := ParseTCXReader(strings.NewReader(multiActivityTCX))
+	if err != nil {
+		t.Fatalf("ParseTCXReader() error = %v", err)
+	}
+
+	activities := tcx.GetActivities()
+
+	want := []struct {
+		id    string
+		sport types.Sport
+	}{
+		{id: "2025-05-01T07:00:00Z", sport: types.SportRunning},
+		{id: "2025-05-02T07:00:00Z", sport: types.SportCycling},
+		{id: "2025-05-03T07:00:00Z", sport: types.SportCycling},
+		{id: "2025-05-03T08:00:00Z", sport: types.SportRunning},
+	}
+
+	if len(activities) != len(want) {
+		t.Fatalf("Got %d activities, want %d", len(activities), len(want))
+	}
+
+	for i, w := range want {
+		if activities[i].Id != w.id || activities[i].Sport != w.sport {
+			t.Errorf("activity %d = %+v, want Id %s and sport %s", i+1, activities[i], w.id, w.sport)
+		}
+	}
+
+	if !activities[0].StartTime.Equal(time.Date(2025, 5, 1, 7, 0, 0, 0, time.UTC)) {
+		t.Errorf("StartTime = %v, want first lap start", activities[0].StartTime)
+	}
+}
+
+func TestSelectActivity(t *testing.T) {
+	tcx, err := ParseTCXReader(strings.NewReader(multiActivityTCX))
+	if err != nil {
+		t.Fatalf("ParseTCXReader() error = %v", err)
+	}
+
+	// The first activity is selected by default
+	if got := tcx.GetSport(); got != types.SportRunning {
+		t.Errorf("GetSport() = %q, want %q", got, types.SportRunning)
+	}
+
+	if err := tcx.SelectActivity(1); err != nil {
+		t.Fatalf("SelectActivity() error = %v", err)
+	}
+
+	if got := tcx.GetSport(); got != types.SportCycling {
+		t.Errorf("GetSport() = %q, want %q", got, types.SportCycling)
+	}
+
+	dataPoints, err := tcx.GetHRDataPoints()
+	if err != nil {
+		t.Fatalf("GetHRDataPoints() error = %v", err)
+	}
+
+	if len(dataPoints) != 1 || dataPoints[0].HeartRate != 130 {
+		t.Errorf("GetHRDataPoints() = %+v, want the second activity's point", dataPoints)
+	}
+
+	for _, index := range []int{-1, 4} {
+		if err := tcx.SelectActivity(index); err == nil {
+			t.Errorf("SelectActivity(%d) expected error", index)
+		}
+	}
+}
+
 func TestGetDeviceInfo(t *testing.T) {
 	tcx, err := ParseTCX("testdata/treadmill_run_watch.tcx")
 	if err != nil {
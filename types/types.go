@@ -1,8 +1,92 @@
 package types
 
-import "time"
+import (
+	"strings"
+	"time"
+)
+
+type Sport string
+
+const (
+	SportRunning Sport = "running"
+	SportCycling Sport = "cycling"
+	SportOther   Sport = "other"
+)
+
+// Normalize a sport name as written by a device or app, e.g. TCX "Biking"
+// or FIT "cycling". Missing, generic and unrecognized names are "", as the
+// sport is unknown rather than some other sport
+func ParseSport(name string) Sport {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "running", "run", "trail_running", "treadmill_running":
+		return SportRunning
+	case "cycling", "biking", "bike", "ride", "road_biking", "mountain_biking", "virtual_ride", "indoor_cycling":
+		return SportCycling
+	case "swimming", "swim", "walking", "walk", "hiking", "hike", "rowing", "row",
+		"cross_country_skiing", "alpine_skiing", "snowboarding", "paddling", "kayaking",
+		"training", "fitness_equipment", "strength_training", "yoga", "elliptical":
+		return SportOther
+	default:
+		return ""
+	}
+}
 
 type HRDataPoint struct {
 	Timestamp time.Time
 	HeartRate int
 }
+
+// A single recorded moment of a workout. Measurements the device didn't
+// record are nil
+type Sample struct {
+	Timestamp time.Time
+	HeartRate *int     // bpm
+	Power     *int     // watts
+	Cadence   *int     // rpm, or strides per minute when running
+	Speed     *float64 // m/s
+	Altitude  *float64 // m
+	Distance  *float64 // m from the start of the workout
+	Position  *Position
+}
+
+// One of several activities in a workout file
+type Activity struct {
+	Id        string
+	Sport     Sport
+	StartTime time.Time
+}
+
+// A lap (or session, for devices that don't record laps) as summarized by
+// the device. Heart rates are 0 when not recorded
+type Lap struct {
+	StartTime    time.Time
+	EndTime      time.Time
+	TotalTime    time.Duration // timer time, excluding pauses
+	AvgHeartRate int
+	MaxHeartRate int
+	Trigger      string // what ended the lap, e.g. "manual", "distance", "time"
+}
+
+type Position struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Project samples onto heart rate data points, skipping samples without a
+// heart rate
+func HRDataPoints(samples []Sample) []HRDataPoint {
+	var dataPoints []HRDataPoint
+
+	for _, sample := range samples {
+		if sample.HeartRate == nil {
+			continue
+		}
+
+		dataPoints = append(dataPoints, HRDataPoint{
+			Timestamp: sample.Timestamp,
+			HeartRate: *sample.HeartRate,
+		})
+	}
+
+	return dataPoints
+}
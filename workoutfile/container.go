@@ -0,0 +1,160 @@
+package workoutfile
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	gzipContainer = "gzip"
+	zipContainer  = "zip"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+// The most bytes read from a container, or unwrapped from it, far more than
+// any workout needs. Guards against decompression bombs
+var maxContainerSize int64 = 256 << 20
+
+var errContainerTooLarge = errors.New("compressed workout is too large")
+
+// Reads r until more than maxContainerSize bytes have been read, then fails
+// with errContainerTooLarge
+type limitedReader struct {
+	r *io.LimitedReader
+}
+
+func limitReader(r io.Reader) io.Reader {
+	return limitedReader{r: &io.LimitedReader{R: r, N: maxContainerSize + 1}}
+}
+
+func (l limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	if l.r.N <= 0 {
+		return n, errContainerTooLarge
+	}
+
+	return n, err
+}
+
+func sniffContainer(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return gzipContainer
+	case bytes.HasPrefix(header, zipMagic):
+		return zipContainer
+	default:
+		return ""
+	}
+}
+
+// Parse the workout inside a gzip stream. The inner file name comes from the
+// gzip header when present, otherwise from name without its .gz extension
+func parseGzip(r io.Reader, name, format string) (WorkoutFile, Detection, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, Detection{}, err
+	}
+	defer gzipReader.Close()
+
+	innerName := gzipReader.Name
+	if innerName == "" {
+		innerName = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	return parseContained(limitReader(gzipReader), innerName, format, gzipContainer)
+}
+
+// Parse the single workout file inside a zip archive
+func parseZip(r io.Reader, format string) (WorkoutFile, Detection, error) {
+	data, err := io.ReadAll(limitReader(r))
+	if err != nil {
+		return nil, Detection{}, err
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, Detection{}, err
+	}
+
+	var entries, workouts []*zip.File
+	for _, file := range archive.File {
+		header, ok := zipEntryHeader(file)
+		if !ok {
+			continue
+		}
+
+		entries = append(entries, file)
+		if isWorkoutEntry(header, file.Name, format) {
+			workouts = append(workouts, file)
+		}
+	}
+	// a lone file is taken to be in the given format, whatever its name
+	if len(workouts) == 0 && format != "" && len(entries) == 1 {
+		workouts = entries
+	}
+
+	switch len(workouts) {
+	case 0:
+		return nil, Detection{}, errors.New("zip archive contains no supported workout files")
+	case 1:
+	default:
+		return nil, Detection{}, fmt.Errorf("zip archive contains %d workout files, extract the one to analyze", len(workouts))
+	}
+
+	entry, err := workouts[0].Open()
+	if err != nil {
+		return nil, Detection{}, err
+	}
+	defer entry.Close()
+
+	return parseContained(limitReader(entry), workouts[0].Name, format, zipContainer)
+}
+
+// The leading bytes of a zip entry that may be a workout file. Directories,
+// hidden files and containers aren't, as only one level is unwrapped
+func zipEntryHeader(file *zip.File) ([]byte, bool) {
+	if file.FileInfo().IsDir() || strings.HasPrefix(path.Base(file.Name), ".") {
+		return nil, false
+	}
+
+	entry, err := file.Open()
+	if err != nil {
+		return nil, false
+	}
+	defer entry.Close()
+
+	header, err := bufio.NewReaderSize(entry, sniffLength).Peek(sniffLength)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, false
+	}
+
+	if sniffContainer(header) != "" {
+		return nil, false
+	}
+
+	return header, true
+}
+
+// Whether a zip entry is a workout file: one in format, by its contents or
+// extension, or in any detectable format when format is empty
+func isWorkoutEntry(header []byte, name, format string) bool {
+	if format == "" {
+		_, err := DetectFormat(header, name)
+		return err == nil
+	}
+
+	format = normalizeFormat(format)
+	return sniffFormat(header) == format || normalizeFormat(path.Ext(name)) == format
+}
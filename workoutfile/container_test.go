@@ -0,0 +1,343 @@
+package workoutfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFile_Gzip(t *testing.T) {
+	tests := []struct {
+		name          string
+		source        string
+		filename      string
+		wantDetection Detection
+	}{
+		{
+			name:          "Strava FIT export",
+			source:        "../fit/testdata/treadmill_run_watch.fit",
+			filename:      "activity.fit.gz",
+			wantDetection: Detection{Format: "fit", Detector: DetectedByContent, Container: "gzip"},
+		},
+		{
+			name:          "Strava TCX export",
+			source:        "../tcx/testdata/treadmill_run_watch.tcx",
+			filename:      "activity.tcx.gz",
+			wantDetection: Detection{Format: "tcx", Detector: DetectedByContent, Container: "gzip"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.filename)
+			writeGzip(t, path, readFile(t, tt.source))
+
+			workoutFile, detection, err := ParseFileWithDetection(path)
+			if err != nil {
+				t.Fatalf("ParseFileWithDetection() error = %v", err)
+			}
+
+			if detection != tt.wantDetection {
+				t.Errorf("Detection = %+v, want %+v", detection, tt.wantDetection)
+			}
+
+			dataPoints, err := workoutFile.GetHRDataPoints()
+			if err != nil {
+				t.Fatalf("GetHRDataPoints() error = %v", err)
+			}
+
+			if len(dataPoints) == 0 {
+				t.Error("Expected HR data points, got none")
+			}
+		})
+	}
+}
+
+func TestParseFile_Zip(t *testing.T) {
+	fitData := readFile(t, "../fit/testdata/treadmill_run_watch.fit")
+	tcxData := readFile(t, "../tcx/testdata/treadmill_run_watch.tcx")
+
+	tests := []struct {
+		name          string
+		entries       map[string][]byte
+		wantDetection Detection
+		wantErr       string
+	}{
+		{
+			name: "Garmin export with a single FIT file",
+			entries: map[string][]byte{
+				"20394857_ACTIVITY.fit": fitData,
+			},
+			wantDetection: Detection{Format: "fit", Detector: DetectedByContent, Container: "zip"},
+		},
+		{
+			name: "workout alongside non-workout files",
+			entries: map[string][]byte{
+				"export/readme.txt":         []byte("exported by Garmin Connect"),
+				"__MACOSX/export/._run.tcx": []byte("resource fork"),
+				"export/run.tcx":            tcxData,
+			},
+			wantDetection: Detection{Format: "tcx", Detector: DetectedByContent, Container: "zip"},
+		},
+		{
+			name: "gzipped workout inside zip",
+			entries: map[string][]byte{
+				"activities/run.fit.gz": gzipBytes(t, fitData),
+			},
+			wantErr: "no supported workout",
+		},
+		{
+			name: "no workouts",
+			entries: map[string][]byte{
+				"readme.txt": []byte("nothing to see here"),
+			},
+			wantErr: "no supported workout",
+		},
+		{
+			name: "several workouts",
+			entries: map[string][]byte{
+				"run.fit": fitData,
+				"run.tcx": tcxData,
+			},
+			wantErr: "2 workout files",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "export.zip")
+			writeZip(t, path, tt.entries)
+
+			workoutFile, detection, err := ParseFileWithDetection(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseFileWithDetection() error = %v, want substring %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseFileWithDetection() error = %v", err)
+			}
+
+			if detection != tt.wantDetection {
+				t.Errorf("Detection = %+v, want %+v", detection, tt.wantDetection)
+			}
+
+			if workoutFile == nil {
+				t.Error("ParseFileWithDetection() returned nil WorkoutFile")
+			}
+		})
+	}
+}
+
+func TestParseWithFormat_Gzip(t *testing.T) {
+	data := gzipBytes(t, readFile(t, "../tcx/testdata/treadmill_run_watch.tcx"))
+
+	workoutFile, detection, err := ParseWithFormat(bytes.NewReader(data), "-", "tcx")
+	if err != nil {
+		t.Fatalf("ParseWithFormat() error = %v", err)
+	}
+
+	want := Detection{Format: "tcx", Detector: DetectedByCaller, Container: "gzip"}
+	if detection != want {
+		t.Errorf("Detection = %+v, want %+v", detection, want)
+	}
+
+	if workoutFile == nil {
+		t.Error("ParseWithFormat() returned nil WorkoutFile")
+	}
+}
+
+func TestParseWithFormat_Zip(t *testing.T) {
+	fitData := readFile(t, "../fit/testdata/treadmill_run_watch.fit")
+	// neither sniffed as hrcsv nor named after it
+	csvData := []byte(strings.Replace(csvContents, "timestamp,heart_rate", "time,bpm", 1))
+	notes := []byte("ran the threshold test in the rain\n")
+
+	tests := []struct {
+		name    string
+		format  string
+		entries map[string][]byte
+		wantErr string
+	}{
+		{
+			name:    "lone file in an unrecognized format",
+			format:  "hrcsv",
+			entries: map[string][]byte{"activity.dat": csvData, ".DS_Store": []byte("junk")},
+		},
+		{
+			name:    "workout in the given format next to another file",
+			format:  "fit",
+			entries: map[string][]byte{"a.fit": fitData, "notes.txt": notes},
+		},
+		{
+			name:    "workout named after the given format next to another file",
+			format:  "hrcsv",
+			entries: map[string][]byte{"activity.hrcsv": csvData, "notes.txt": notes},
+		},
+		{
+			name:    "unrecognized file next to another file",
+			format:  "hrcsv",
+			entries: map[string][]byte{"activity.dat": csvData, "notes.txt": notes},
+			wantErr: "zip archive contains no supported workout files",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "export.zip")
+			writeZip(t, path, tt.entries)
+
+			workoutFile, detection, err := ParseWithFormat(bytes.NewReader(readFile(t, path)), "-", tt.format)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseWithFormat() error = %v, want substring %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseWithFormat() error = %v", err)
+			}
+
+			want := Detection{Format: tt.format, Detector: DetectedByCaller, Container: "zip"}
+			if detection != want {
+				t.Errorf("Detection = %+v, want %+v", detection, want)
+			}
+
+			hrData, err := workoutFile.GetHRDataPoints()
+			if err != nil || len(hrData) == 0 {
+				t.Errorf("GetHRDataPoints() = %d data points, %v, want some", len(hrData), err)
+			}
+		})
+	}
+}
+
+func TestParseWithDetection_Nested(t *testing.T) {
+	fitData := readFile(t, "../fit/testdata/treadmill_run_watch.fit")
+
+	var zipData bytes.Buffer
+	writer := zip.NewWriter(&zipData)
+	entry, err := writer.Create("run.fit")
+	if err != nil {
+		t.Fatalf("failed to add run.fit to zip: %v", err)
+	}
+	if _, err := entry.Write(fitData); err != nil {
+		t.Fatalf("failed to add run.fit to zip: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to write zip: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{
+			name:    "gzip inside gzip",
+			data:    gzipBytes(t, gzipBytes(t, fitData)),
+			wantErr: "gzip inside gzip is not supported",
+		},
+		{
+			name:    "zip inside gzip",
+			data:    gzipBytes(t, zipData.Bytes()),
+			wantErr: "zip inside gzip is not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ParseWithDetection(bytes.NewReader(tt.data), "run.fit.gz")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseWithDetection() error = %v, want substring %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseWithDetection_TooLarge(t *testing.T) {
+	limit := maxContainerSize
+	maxContainerSize = 1 << 10
+	t.Cleanup(func() { maxContainerSize = limit })
+
+	tcxData := readFile(t, "../tcx/testdata/treadmill_run_watch.tcx")
+
+	_, _, err := ParseWithDetection(bytes.NewReader(gzipBytes(t, tcxData)), "run.tcx.gz")
+	if !errors.Is(err, errContainerTooLarge) {
+		t.Errorf("ParseWithDetection() error = %v, want errContainerTooLarge", err)
+	}
+}
+
+func TestDetection_String(t *testing.T) {
+	detection := Detection{Format: "fit", Detector: DetectedByContent, Container: "gzip"}
+
+	if got, want := detection.String(), "fit in gzip (detected by content)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+
+	return data
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatalf("failed to gzip data: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to gzip data: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func writeGzip(t *testing.T, path string, data []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(path, gzipBytes(t, data), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func writeZip(t *testing.T, path string, entries map[string][]byte) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create %s: %v", path, err)
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	for name, data := range entries {
+		entry, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("failed to add %s to zip: %v", name, err)
+		}
+		if _, err := entry.Write(data); err != nil {
+			t.Fatalf("failed to add %s to zip: %v", name, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
@@ -1,22 +1,107 @@
 package workoutfile
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
-	"path/filepath"
-	"strings"
-	"zone-finder/fit"
-	"zone-finder/tcx"
+	"io"
+	"os"
 )
 
 func ParseFile(path string) (WorkoutFile, error) {
-	ext := strings.ToLower(filepath.Ext(path))
+	workout, _, err := ParseFileWithDetection(path)
+	return workout, err
+}
+
+// Parse a workout file, reporting how its format was detected
+func ParseFileWithDetection(path string) (WorkoutFile, Detection, error) {
+	workoutFile, err := os.Open(path)
+	if err != nil {
+		return nil, Detection{}, err
+	}
+	defer workoutFile.Close()
+
+	return ParseWithDetection(workoutFile, path)
+}
+
+// Parse a workout from r, sniffing its format from the contents and
+// unwrapping a gzip or zip container. name is only consulted for its
+// extension when sniffing is inconclusive
+func ParseWithDetection(r io.Reader, name string) (WorkoutFile, Detection, error) {
+	return ParseWithFormat(r, name, "")
+}
+
+// Parse a workout from r like ParseWithDetection, but in the given format
+// rather than the detected one. Only the format of the workout itself is
+// given, so a gzip or zip container is still unwrapped. Detection is as
+// ParseWithDetection when format is empty
+func ParseWithFormat(r io.Reader, name, format string) (WorkoutFile, Detection, error) {
+	buffered := bufio.NewReaderSize(r, sniffLength)
+
+	header, err := buffered.Peek(sniffLength)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, Detection{}, err
+	}
+	if len(header) == 0 {
+		return nil, Detection{}, ErrEmptyWorkout
+	}
+
+	switch sniffContainer(header) {
+	case gzipContainer:
+		return parseGzip(buffered, name, format)
+	case zipContainer:
+		return parseZip(buffered, format)
+	}
+
+	return parseSniffed(buffered, header, name, format)
+}
 
-	switch ext {
-	case ".tcx":
-		return tcx.ParseTCX(path)
-	case ".fit":
-		return fit.ParseFIT(path)
-	default:
-		return nil, fmt.Errorf("unsupported file format %s", ext)
+// Parse a workout unwrapped from a container, which mustn't be another
+// container
+func parseContained(r io.Reader, name, format, container string) (WorkoutFile, Detection, error) {
+	buffered := bufio.NewReaderSize(r, sniffLength)
+
+	header, err := buffered.Peek(sniffLength)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, Detection{}, err
+	}
+	if len(header) == 0 {
+		return nil, Detection{}, fmt.Errorf("%s: %w", container, ErrEmptyWorkout)
+	}
+
+	if nested := sniffContainer(header); nested != "" {
+		return nil, Detection{}, fmt.Errorf("%s inside %s is not supported, extract the workout file", nested, container)
+	}
+
+	workout, detection, err := parseSniffed(buffered, header, name, format)
+	detection.Container = container
+	return workout, detection, err
+}
+
+// Parse a workout whose first bytes, header, have been peeked from buffered,
+// in format, or the format detected when empty
+func parseSniffed(buffered *bufio.Reader, header []byte, name, format string) (WorkoutFile, Detection, error) {
+	if format != "" {
+		workout, err := Parse(buffered, format)
+		return workout, Detection{Format: format, Detector: DetectedByCaller}, err
 	}
+
+	detection, err := DetectFormat(header, name)
+	if err != nil {
+		return nil, Detection{}, err
+	}
+
+	workout, err := Parse(buffered, detection.Format)
+	return workout, detection, err
+}
+
+// Parse a workout from r. format is a registered format name such as "tcx"
+// or ".fit"
+func Parse(r io.Reader, format string) (WorkoutFile, error) {
+	f, err := lookupFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.parse(r)
 }
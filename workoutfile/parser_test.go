@@ -1,6 +1,10 @@
 package workoutfile
 
 import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -28,16 +32,21 @@ func TestParseFile(t *testing.T) {
 			wantErr: false, // Should handle case-insensitive
 		},
 		{
-			name:    "unsupported format - GPX",
-			path:    "workout.gpx",
+			name:    "valid GPX file",
+			path:    "../gpx/testdata/outside_run_armband.gpx",
+			wantErr: false,
+		},
+		{
+			name:    "unsupported format - KML",
+			path:    "testdata/route.kml",
 			wantErr: true,
 			errMsg:  "unsupported",
 		},
 		{
 			name:    "unsupported format - no extension",
-			path:    "workout",
+			path:    "testdata/notes",
 			wantErr: true,
-			errMsg:  "unsupported",
+			errMsg:  "could not detect workout format",
 		},
 		{
 			name:    "non-existent file",
@@ -87,6 +96,11 @@ func TestParseFile_ExtractsHRData(t *testing.T) {
 			path:     "../fit/testdata/treadmill_run_watch.fit",
 			wantData: true,
 		},
+		{
+			name:     "GPX file has HR data",
+			path:     "../gpx/testdata/outside_run_armband.gpx",
+			wantData: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -129,6 +143,10 @@ func TestParseFile_ExtractsDeviceInfo(t *testing.T) {
 			name: "FIT file",
 			path: "../fit/testdata/treadmill_run_watch.fit",
 		},
+		{
+			name: "GPX file",
+			path: "../gpx/testdata/outside_run_armband.gpx",
+		},
 	}
 
 	for _, tt := range tests {
@@ -150,3 +168,169 @@ func TestParseFile_ExtractsDeviceInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestParse_FromReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		format  string
+		wantErr bool
+	}{
+		{
+			name:   "TCX reader",
+			path:   "../tcx/testdata/treadmill_run_watch.tcx",
+			format: "tcx",
+		},
+		{
+			name:   "FIT reader with dotted uppercase format",
+			path:   "../fit/testdata/treadmill_run_watch.fit",
+			format: ".FIT",
+		},
+		{
+			name:   "GPX reader",
+			path:   "../gpx/testdata/outside_run_armband.gpx",
+			format: "gpx",
+		},
+		{
+			name:    "unsupported format",
+			path:    "../tcx/testdata/treadmill_run_watch.tcx",
+			format:  "kml",
+			wantErr: true,
+		},
+		{
+			name:    "mismatched format",
+			path:    "../tcx/testdata/treadmill_run_watch.tcx",
+			format:  "fit",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile(tt.path)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", tt.path, err)
+			}
+
+			workoutFile, err := Parse(bytes.NewReader(data), tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			dataPoints, err := workoutFile.GetHRDataPoints()
+			if err != nil {
+				t.Fatalf("GetHRDataPoints() error = %v", err)
+			}
+
+			if len(dataPoints) == 0 {
+				t.Error("Expected HR data points, got none")
+			}
+		})
+	}
+}
+
+func TestParseWithFormat_Undetectable(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		format  string
+		wantErr error
+	}{
+		{
+			name:    "empty stdin",
+			wantErr: ErrEmptyWorkout,
+		},
+		{
+			name:    "empty stdin with a format",
+			format:  "fit",
+			wantErr: ErrEmptyWorkout,
+		},
+		{
+			name:    "unknown contents on stdin",
+			data:    "hello",
+			wantErr: ErrFormatNotDetected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ParseWithFormat(strings.NewReader(tt.data), "-", tt.format)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseWithFormat() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseFileWithDetection(t *testing.T) {
+	tests := []struct {
+		name         string
+		source       string
+		copyAs       string
+		wantFormat   string
+		wantDetector string
+	}{
+		{
+			name:         "FIT saved with a .bin extension",
+			source:       "../fit/testdata/treadmill_run_watch.fit",
+			copyAs:       "activity.bin",
+			wantFormat:   "fit",
+			wantDetector: DetectedByContent,
+		},
+		{
+			name:         "TCX downloaded as .xml",
+			source:       "../tcx/testdata/treadmill_run_watch.tcx",
+			copyAs:       "activity.xml",
+			wantFormat:   "tcx",
+			wantDetector: DetectedByContent,
+		},
+		{
+			name:         "GPX without an extension",
+			source:       "../gpx/testdata/outside_run_armband.gpx",
+			copyAs:       "activity",
+			wantFormat:   "gpx",
+			wantDetector: DetectedByContent,
+		},
+		{
+			name:         "FIT with a misleading .tcx extension",
+			source:       "../fit/testdata/treadmill_run_watch.fit",
+			copyAs:       "activity.tcx",
+			wantFormat:   "fit",
+			wantDetector: DetectedByContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := copyToTempFile(t, tt.source, tt.copyAs)
+
+			workoutFile, detection, err := ParseFileWithDetection(path)
+			if err != nil {
+				t.Fatalf("ParseFileWithDetection() error = %v", err)
+			}
+
+			if detection.Format != tt.wantFormat || detection.Detector != tt.wantDetector {
+				t.Errorf("Detection = %+v, want format %s detected by %s", detection, tt.wantFormat, tt.wantDetector)
+			}
+
+			if workoutFile == nil {
+				t.Error("ParseFileWithDetection() returned nil WorkoutFile")
+			}
+		})
+	}
+}
+
+func copyToTempFile(t *testing.T, source string, name string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, readFile(t, source), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+
+	return path
+}
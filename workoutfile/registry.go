@@ -0,0 +1,121 @@
+package workoutfile
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"zone-finder/fit"
+	"zone-finder/gpx"
+	"zone-finder/tcx"
+)
+
+// Reports whether header, the leading bytes of a file, is in a given format
+type SniffFunc func(header []byte) bool
+
+// Parses a workout in a given format
+type ParseFunc func(r io.Reader) (WorkoutFile, error)
+
+type format struct {
+	name  string
+	sniff SniffFunc
+	parse ParseFunc
+}
+
+var (
+	registryMu sync.RWMutex
+	// in registration order, which is also the order formats are sniffed in
+	registry []format
+)
+
+func init() {
+	Register("tcx", SniffXMLRoot("TrainingCenterDatabase"), func(r io.Reader) (WorkoutFile, error) {
+		return tcx.ParseTCXReader(r)
+	})
+	Register("fit", isFITHeader, func(r io.Reader) (WorkoutFile, error) {
+		return fit.ParseFITReader(r)
+	})
+	Register("gpx", SniffXMLRoot("gpx"), func(r io.Reader) (WorkoutFile, error) {
+		return gpx.ParseGPXReader(r)
+	})
+}
+
+// Register makes a workout format available to ParseFile and Parse. name is
+// also matched against file extensions when sniffing is inconclusive, and
+// sniff may be nil for formats that can only be recognized by extension.
+// Register panics if name is empty or already registered, or if parse is nil
+func Register(name string, sniff SniffFunc, parse ParseFunc) {
+	name = normalizeFormat(name)
+	if name == "" {
+		panic("workoutfile: Register format name is empty")
+	}
+	if parse == nil {
+		panic("workoutfile: Register parse func is nil for " + name)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, f := range registry {
+		if f.name == name {
+			panic("workoutfile: Register called twice for " + name)
+		}
+	}
+
+	registry = append(registry, format{name: name, sniff: sniff, parse: parse})
+}
+
+// Names of the registered formats, sorted
+func Formats() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, len(registry))
+	for i, f := range registry {
+		names[i] = f.name
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// SniffXMLRoot returns a SniffFunc matching XML documents whose root element
+// has the given local name
+func SniffXMLRoot(local string) SniffFunc {
+	return func(header []byte) bool {
+		return xmlRootElement(header) == local
+	}
+}
+
+func lookupFormat(name string) (format, error) {
+	name = normalizeFormat(name)
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, f := range registry {
+		if f.name == name {
+			return f, nil
+		}
+	}
+
+	return format{}, fmt.Errorf("unsupported file format %s", name)
+}
+
+func sniffFormat(header []byte) string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, f := range registry {
+		if f.sniff != nil && f.sniff(header) {
+			return f.name
+		}
+	}
+
+	return ""
+}
+
+func normalizeFormat(name string) string {
+	return strings.TrimPrefix(strings.ToLower(name), ".")
+}
@@ -0,0 +1,206 @@
+package workoutfile
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"zone-finder/types"
+)
+
+// A minimal in-house format: a header line followed by "RFC3339,bpm" rows
+type csvWorkout struct {
+	dataPoints []types.HRDataPoint
+}
+
+func (c *csvWorkout) GetHRDataPoints() ([]types.HRDataPoint, error) { return c.dataPoints, nil }
+func (c *csvWorkout) GetDeviceName() string                         { return "CSV" }
+func (c *csvWorkout) GetProductID() int                             { return 0 }
+
+func parseCSVWorkout(r io.Reader) (WorkoutFile, error) {
+	workout := &csvWorkout{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		timestamp, heartRate, _ := strings.Cut(scanner.Text(), ",")
+
+		parsedTime, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil {
+			return nil, err
+		}
+
+		parsedHR, err := strconv.Atoi(heartRate)
+		if err != nil {
+			return nil, err
+		}
+
+		workout.dataPoints = append(workout.dataPoints, types.HRDataPoint{Timestamp: parsedTime, HeartRate: parsedHR})
+	}
+
+	return workout, scanner.Err()
+}
+
+func init() {
+	Register("hrcsv", func(header []byte) bool {
+		return bytes.HasPrefix(header, []byte("timestamp,heart_rate\n"))
+	}, parseCSVWorkout)
+}
+
+const csvContents = "timestamp,heart_rate\n2025-10-28T18:42:51Z,110\n2025-10-28T18:42:52Z,112\n"
+
+func TestRegister_ExternalFormat(t *testing.T) {
+	tests := []struct {
+		name         string
+		filename     string
+		contents     string
+		wantDetector string
+	}{
+		{
+			name:         "sniffed from contents",
+			filename:     "workout.txt",
+			contents:     csvContents,
+			wantDetector: DetectedByContent,
+		},
+		{
+			name:         "matched by extension",
+			filename:     "workout.HRCSV",
+			contents:     "2025-10-28T18:42:51Z,110\n2025-10-28T18:42:52Z,112\n",
+			wantDetector: DetectedByExtension,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workoutFile, detection, err := ParseWithDetection(strings.NewReader(tt.contents), filepath.Join("exports", tt.filename))
+			if err != nil {
+				t.Fatalf("ParseWithDetection() error = %v", err)
+			}
+
+			if detection.Format != "hrcsv" || detection.Detector != tt.wantDetector {
+				t.Errorf("Detection = %+v, want hrcsv detected by %s", detection, tt.wantDetector)
+			}
+
+			if workoutFile.GetDeviceName() != "CSV" {
+				t.Errorf("DeviceName = %v, want CSV", workoutFile.GetDeviceName())
+			}
+		})
+	}
+}
+
+func TestParse_RegisteredFormat(t *testing.T) {
+	workoutFile, err := Parse(strings.NewReader(csvContents), "HRCSV")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	dataPoints, err := workoutFile.GetHRDataPoints()
+	if err != nil {
+		t.Fatalf("GetHRDataPoints() error = %v", err)
+	}
+
+	if len(dataPoints) != 2 || dataPoints[1].HeartRate != 112 {
+		t.Errorf("GetHRDataPoints() = %+v, want 2 points ending at 112 bpm", dataPoints)
+	}
+}
+
+func TestRegister_Panics(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		parse  ParseFunc
+	}{
+		{
+			name:   "duplicate name",
+			format: ".TCX",
+			parse:  parseCSVWorkout,
+		},
+		{
+			name:   "empty name",
+			format: "",
+			parse:  parseCSVWorkout,
+		},
+		{
+			name:   "nil parse func",
+			format: "nilparse",
+			parse:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register(%q) did not panic", tt.format)
+				}
+			}()
+
+			Register(tt.format, nil, tt.parse)
+		})
+	}
+}
+
+func TestFormats(t *testing.T) {
+	formats := Formats()
+
+	for _, want := range []string{"fit", "gpx", "hrcsv", "tcx"} {
+		if !slices.Contains(formats, want) {
+			t.Errorf("Formats() = %v, missing %s", formats, want)
+		}
+	}
+
+	if !slices.IsSorted(formats) {
+		t.Errorf("Formats() = %v, want sorted", formats)
+	}
+}
+
+func TestOptionalMethods_ExternalFormat(t *testing.T) {
+	workoutFile, err := Parse(strings.NewReader(csvContents), "hrcsv")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	samples, err := Samples(workoutFile)
+	if err != nil {
+		t.Fatalf("Samples() error = %v", err)
+	}
+	if len(samples) != 2 || samples[1].HeartRate == nil || *samples[1].HeartRate != 112 || samples[1].Power != nil {
+		t.Errorf("Samples() = %+v, want 2 heart rate only samples ending at 112 bpm", samples)
+	}
+
+	if laps, err := Laps(workoutFile); laps != nil || err != nil {
+		t.Errorf("Laps() = %v, %v, want no laps", laps, err)
+	}
+
+	if sport := Sport(workoutFile); sport != "" {
+		t.Errorf("Sport() = %q, want \"\"", sport)
+	}
+}
+
+func TestOptionalMethods_BuiltInFormats(t *testing.T) {
+	for _, path := range []string{
+		"../fit/testdata/treadmill_run_watch.fit",
+		"../tcx/testdata/treadmill_run_watch.tcx",
+		"../gpx/testdata/outside_run_armband.gpx",
+	} {
+		workoutFile, err := ParseFile(path)
+		if err != nil {
+			t.Fatalf("ParseFile(%s) error = %v", path, err)
+		}
+
+		if _, ok := workoutFile.(SampleFile); !ok {
+			t.Errorf("%s: not a SampleFile", path)
+		}
+		if _, ok := workoutFile.(LapFile); !ok {
+			t.Errorf("%s: not a LapFile", path)
+		}
+		if sport := Sport(workoutFile); sport != types.SportRunning {
+			t.Errorf("%s: Sport() = %q, want %q", path, sport, types.SportRunning)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package workoutfile
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Number of leading bytes inspected when sniffing a workout's format
+const sniffLength = 4096
+
+const (
+	DetectedByContent   = "content"
+	DetectedByExtension = "extension"
+	// The format was given to ParseWithFormat
+	DetectedByCaller = "caller"
+)
+
+var (
+	// Neither the contents nor the name's extension tell the workout's
+	// format, e.g. when reading unrecognised contents from stdin
+	ErrFormatNotDetected = errors.New("could not detect workout format")
+	// The workout has no contents at all
+	ErrEmptyWorkout = errors.New("workout file is empty")
+)
+
+// How a workout's format was determined
+type Detection struct {
+	Format   string
+	Detector string
+	// Archive or compression the workout was unwrapped from, if any
+	Container string
+}
+
+func (d Detection) String() string {
+	if d.Container == "" {
+		return fmt.Sprintf("%s (detected by %s)", d.Format, d.Detector)
+	}
+
+	return fmt.Sprintf("%s in %s (detected by %s)", d.Format, d.Container, d.Detector)
+}
+
+// Detect a workout's format from its leading bytes, falling back to the
+// extension of name when the contents are inconclusive. XML with a root
+// element no format sniffs is conclusive, and only falls back to formats
+// recognized by extension alone
+func DetectFormat(header []byte, name string) (Detection, error) {
+	if format := sniffFormat(header); format != "" {
+		return Detection{Format: format, Detector: DetectedByContent}, nil
+	}
+
+	root := xmlRootElement(header)
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == "" {
+		if root != "" {
+			return Detection{}, fmt.Errorf("unsupported format (XML root %s)", root)
+		}
+		return Detection{}, ErrFormatNotDetected
+	}
+
+	format, err := lookupFormat(ext)
+	if err != nil {
+		return Detection{}, fmt.Errorf("unsupported file format %s", ext)
+	}
+	if root != "" && format.sniff != nil {
+		return Detection{}, fmt.Errorf("unsupported format (XML root %s)", root)
+	}
+
+	return Detection{Format: format.name, Detector: DetectedByExtension}, nil
+}
+
+// FIT files start with a 12 or 14 byte header carrying the ".FIT" signature
+func isFITHeader(header []byte) bool {
+	if len(header) < 12 {
+		return false
+	}
+
+	headerSize := int(header[0])
+	if headerSize != 12 && headerSize != 14 {
+		return false
+	}
+
+	return string(header[8:12]) == ".FIT"
+}
+
+func xmlRootElement(header []byte) string {
+	header = bytes.TrimPrefix(header, []byte("\xef\xbb\xbf"))
+
+	decoder := xml.NewDecoder(bytes.NewReader(header))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package workoutfile
+
+import "testing"
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       string
+		filename     string
+		wantFormat   string
+		wantDetector string
+		wantErr      bool
+	}{
+		{
+			name:         "TCX root with byte order mark",
+			header:       "\xef\xbb\xbf<?xml version=\"1.0\"?>\n<TrainingCenterDatabase>",
+			filename:     "activity.txt",
+			wantFormat:   "tcx",
+			wantDetector: DetectedByContent,
+		},
+		{
+			name:         "GPX root after a comment",
+			header:       "<?xml version=\"1.0\"?>\n<!-- exported -->\n<gpx version=\"1.1\">",
+			filename:     "activity.txt",
+			wantFormat:   "gpx",
+			wantDetector: DetectedByContent,
+		},
+		{
+			name:         "truncated file falls back to extension",
+			header:       "<?xml",
+			filename:     "activity.TCX",
+			wantFormat:   "tcx",
+			wantDetector: DetectedByExtension,
+		},
+		{
+			name:     "unknown XML root isn't taken for the extension's format",
+			header:   "<?xml version=\"1.0\"?>\n<kml xmlns=\"http://www.opengis.net/kml/2.2\">",
+			filename: "route.gpx",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown XML root without an extension",
+			header:   "<kml>",
+			filename: "-",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown contents and extension",
+			header:   "hello",
+			filename: "activity.txt",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown contents without an extension",
+			header:   "hello",
+			filename: "-",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detection, err := DetectFormat([]byte(tt.header), tt.filename)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DetectFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if detection.Format != tt.wantFormat || detection.Detector != tt.wantDetector {
+				t.Errorf("DetectFormat() = %+v, want format %q detected by %q", detection, tt.wantFormat, tt.wantDetector)
+			}
+		})
+	}
+}
@@ -7,3 +7,71 @@ type WorkoutFile interface {
 	GetDeviceName() string
 	GetProductID() int
 }
+
+// Implemented by workout files recording more than heart rate, such as
+// power, cadence and position
+type SampleFile interface {
+	WorkoutFile
+	GetSamples() ([]types.Sample, error)
+}
+
+// Implemented by workout files recording laps, or sessions for devices that
+// don't record laps
+type LapFile interface {
+	WorkoutFile
+	GetLaps() ([]types.Lap, error)
+}
+
+// Implemented by workout files recording the sport of the workout
+type SportFile interface {
+	WorkoutFile
+	GetSport() types.Sport
+}
+
+// Implemented by workout files that can hold several activities, such as
+// TCX. The WorkoutFile methods report on the selected activity, which is
+// the first one unless SelectActivity is called
+type MultiActivityFile interface {
+	WorkoutFile
+	GetActivities() []types.Activity
+	SelectActivity(index int) error
+}
+
+// The samples of a workout, or just its heart rates when it isn't a
+// SampleFile
+func Samples(workout WorkoutFile) ([]types.Sample, error) {
+	if samples, ok := workout.(SampleFile); ok {
+		return samples.GetSamples()
+	}
+
+	hrData, err := workout.GetHRDataPoints()
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]types.Sample, len(hrData))
+	for i, dp := range hrData {
+		heartRate := dp.HeartRate
+		samples[i] = types.Sample{Timestamp: dp.Timestamp, HeartRate: &heartRate}
+	}
+
+	return samples, nil
+}
+
+// The laps of a workout, or none when it isn't a LapFile
+func Laps(workout WorkoutFile) ([]types.Lap, error) {
+	if laps, ok := workout.(LapFile); ok {
+		return laps.GetLaps()
+	}
+
+	return nil, nil
+}
+
+// The sport of a workout, or "" when it isn't a SportFile
+func Sport(workout WorkoutFile) types.Sport {
+	if sport, ok := workout.(SportFile); ok {
+		return sport.GetSport()
+	}
+
+	return ""
+}
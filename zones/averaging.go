@@ -0,0 +1,105 @@
+package zones
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"zone-finder/types"
+)
+
+// How heart rate samples are averaged when selecting a window and
+// calculating LTHR
+type Averaging int
+
+const (
+	// Every sample counts equally, regardless of how often the device
+	// recorded
+	AveragingSampleMean Averaging = iota
+	// Each sample is weighted by the time until the next one, so devices
+	// that record sparsely during steady efforts ("smart recording") don't
+	// bias the average towards the changes in between
+	AveragingTimeWeighted
+)
+
+// Longest a sample's heart rate is held when time weighting, so pauses and
+// dropouts don't dominate the average. Also the default gap threshold
+const maxSampleHold = 30 * time.Second
+
+var averagingNames = map[Averaging]string{
+	AveragingSampleMean:   "sample",
+	AveragingTimeWeighted: "time",
+}
+
+func (a Averaging) String() string {
+	if name, ok := averagingNames[a]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Averaging(%d)", int(a))
+}
+
+// Parse an averaging name as returned by Averaging.String
+func ParseAveraging(name string) (Averaging, error) {
+	for averaging, averagingName := range averagingNames {
+		if strings.EqualFold(name, averagingName) {
+			return averaging, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown averaging %q", name)
+}
+
+// Average heart rate of a workout or part of one, e.g. a lap, averaged as
+// specified
+func AverageHeartRate(dataPoints []types.HRDataPoint, averaging Averaging) (float64, error) {
+	if len(dataPoints) == 0 {
+		return 0, ErrNoHRData
+	}
+
+	sortByTimestamp(dataPoints)
+	return averageHeartRate(dataPoints, averaging), nil
+}
+
+// Average heart rate of data points sorted by timestamp, or 0 without any
+func averageHeartRate(dataPoints []types.HRDataPoint, averaging Averaging) float64 {
+	if len(dataPoints) == 0 {
+		return 0
+	}
+
+	var weighted, total int64
+
+	for i := range dataPoints[:len(dataPoints)-averaging.lag()] {
+		weight := averaging.weight(dataPoints, i)
+		weighted += int64(dataPoints[i].HeartRate) * weight
+		total += weight
+	}
+
+	if total == 0 && averaging != AveragingSampleMean {
+		// a single sample, or several with the same timestamp
+		return averageHeartRate(dataPoints, AveragingSampleMean)
+	}
+
+	return float64(weighted) / float64(total)
+}
+
+// Number of trailing samples in a window that don't contribute to its
+// average. A time-weighted sample holds until the next one, so the last
+// sample of a window covers no time within it
+func (a Averaging) lag() int {
+	if a == AveragingTimeWeighted {
+		return 1
+	}
+
+	return 0
+}
+
+// Weight of the sample at index i, which must have a successor when time
+// weighting
+func (a Averaging) weight(dataPoints []types.HRDataPoint, i int) int64 {
+	if a != AveragingTimeWeighted {
+		return 1
+	}
+
+	hold := min(dataPoints[i+1].Timestamp.Sub(dataPoints[i].Timestamp), maxSampleHold)
+	return int64(hold)
+}
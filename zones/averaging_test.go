@@ -0,0 +1,167 @@
+package zones
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"zone-finder/types"
+)
+
+// Constant heart rate recorded every interval, like a device using smart
+// recording during a steady effort
+func createSparseHR(startTime time.Time, hr int, duration, interval time.Duration) []types.HRDataPoint {
+	var data []types.HRDataPoint
+	for offset := time.Duration(0); offset < duration; offset += interval {
+		data = append(data, types.HRDataPoint{Timestamp: startTime.Add(offset), HeartRate: hr})
+	}
+	return data
+}
+
+// A steady effort recorded every 10 seconds, with a 2 minute surge the
+// device recorded every second
+func createSmartRecording(baseTime time.Time) []types.HRDataPoint {
+	var data []types.HRDataPoint
+	data = append(data, createSparseHR(baseTime, 160, 10*time.Minute, 10*time.Second)...)
+	data = append(data, createSparseHR(baseTime.Add(10*time.Minute), 180, 2*time.Minute, time.Second)...)
+	data = append(data, createSparseHR(baseTime.Add(12*time.Minute), 160, 13*time.Minute, 10*time.Second)...)
+	return data
+}
+
+func TestFindLTHRWithOptions_Averaging(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		data      []types.HRDataPoint
+		averaging Averaging
+		wantLTHR  int
+	}{
+		{
+			name:      "sample mean is biased towards the densely recorded surge",
+			data:      createSmartRecording(baseTime),
+			averaging: AveragingSampleMean,
+			wantLTHR:  170,
+		},
+		{
+			name:      "time weighted counts the surge for its 2 minutes",
+			data:      createSmartRecording(baseTime),
+			averaging: AveragingTimeWeighted,
+			wantLTHR:  162,
+		},
+		{
+			name:      "an hour recorded every 15 seconds has no gaps",
+			data:      createSparseHR(baseTime, 165, time.Hour, 15*time.Second),
+			averaging: AveragingTimeWeighted,
+			wantLTHR:  165,
+		},
+		{
+			name:      "averaging doesn't matter for 1 Hz recordings",
+			data:      createTimeTrial(baseTime),
+			averaging: AveragingTimeWeighted,
+			wantLTHR:  177,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lthr, err := FindLTHRWithOptions(tt.data, Options{Averaging: tt.averaging})
+			if err != nil {
+				t.Fatalf("FindLTHRWithOptions() error = %v", err)
+			}
+
+			if lthr < tt.wantLTHR-1 || lthr > tt.wantLTHR+1 {
+				t.Errorf("LTHR = %d, want ~%d (±1)", lthr, tt.wantLTHR)
+			}
+		})
+	}
+}
+
+func TestAverageHeartRate_TimeWeighted(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		data    []types.HRDataPoint
+		wantAvg float64
+	}{
+		{
+			name: "weighted by time until the next sample",
+			data: []types.HRDataPoint{
+				{Timestamp: baseTime, HeartRate: 150},
+				{Timestamp: baseTime.Add(20 * time.Second), HeartRate: 180},
+				{Timestamp: baseTime.Add(25 * time.Second), HeartRate: 100},
+			},
+			wantAvg: 156, // (150*20 + 180*5) / 25
+		},
+		{
+			name: "samples before a pause are held for at most 30 seconds",
+			data: []types.HRDataPoint{
+				{Timestamp: baseTime, HeartRate: 100},
+				{Timestamp: baseTime.Add(10 * time.Minute), HeartRate: 170},
+				{Timestamp: baseTime.Add(10*time.Minute + 10*time.Second), HeartRate: 170},
+			},
+			wantAvg: 117.5, // (100*30 + 170*10) / 40
+		},
+		{
+			name:    "single sample",
+			data:    []types.HRDataPoint{{Timestamp: baseTime, HeartRate: 165}},
+			wantAvg: 165,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AverageHeartRate(tt.data, AveragingTimeWeighted)
+			if err != nil {
+				t.Fatalf("AverageHeartRate() error = %v", err)
+			}
+
+			if got != tt.wantAvg {
+				t.Errorf("AverageHeartRate() = %.1f, want %.1f", got, tt.wantAvg)
+			}
+		})
+	}
+}
+
+func TestAverageHeartRate(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	data := createSmartRecording(baseTime)
+
+	sampleMean, err := AverageHeartRate(data, AveragingSampleMean)
+	if err != nil {
+		t.Fatalf("AverageHeartRate() error = %v", err)
+	}
+
+	timeWeighted, err := AverageHeartRate(data, AveragingTimeWeighted)
+	if err != nil {
+		t.Fatalf("AverageHeartRate() error = %v", err)
+	}
+
+	// the surge is most of the samples but under a tenth of the time
+	if sampleMean < 169 || timeWeighted > 162 {
+		t.Errorf("AverageHeartRate() = %.1f sample mean, %.1f time weighted, want ~169 and ~162", sampleMean, timeWeighted)
+	}
+
+	for _, averaging := range []Averaging{AveragingSampleMean, AveragingTimeWeighted} {
+		if _, err := AverageHeartRate(nil, averaging); !errors.Is(err, ErrNoHRData) {
+			t.Errorf("AverageHeartRate() error = %v with %s averaging, want ErrNoHRData", err, averaging)
+		}
+	}
+}
+
+func TestParseAveraging(t *testing.T) {
+	for _, averaging := range []Averaging{AveragingSampleMean, AveragingTimeWeighted} {
+		parsed, err := ParseAveraging(averaging.String())
+		if err != nil {
+			t.Errorf("ParseAveraging(%q) error = %v", averaging, err)
+		}
+
+		if parsed != averaging {
+			t.Errorf("ParseAveraging(%q) = %v, want %v", averaging, parsed, averaging)
+		}
+	}
+
+	if _, err := ParseAveraging("median"); err == nil {
+		t.Error("ParseAveraging() expected error for unknown averaging")
+	}
+}
@@ -0,0 +1,39 @@
+package zones
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// The workout has no heart rate data to find LTHR from
+	ErrNoHRData = errors.New("no HR data provided")
+	// No window of the workout qualifies, e.g. because of gaps in the
+	// heart rate data
+	ErrNoValidWindow = errors.New("could not find a valid window")
+)
+
+// The workout's heart rate data spans less time than the window requires
+type TooShortError struct {
+	Have time.Duration
+	Need time.Duration
+}
+
+func (e *TooShortError) Error() string {
+	return fmt.Sprintf("workout too short: need at least %v", e.Need)
+}
+
+// Every window long enough has too little heart rate coverage
+type CoverageError struct {
+	Window      time.Duration
+	MinCoverage float64
+}
+
+func (e *CoverageError) Error() string {
+	return fmt.Sprintf("every %v window has less than %s heart rate coverage", e.Window, formatPercent(e.MinCoverage))
+}
+
+func (e *CoverageError) Unwrap() error {
+	return ErrNoValidWindow
+}
@@ -0,0 +1,111 @@
+package zones
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"zone-finder/types"
+)
+
+func TestFindLTHR_Errors(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("no HR data", func(t *testing.T) {
+		_, err := FindLTHR(nil)
+		if !errors.Is(err, ErrNoHRData) {
+			t.Errorf("FindLTHR() error = %v, want ErrNoHRData", err)
+		}
+	})
+
+	t.Run("too short", func(t *testing.T) {
+		_, err := FindLTHR(createConstantHR(baseTime, 160, 19*60))
+
+		var tooShort *TooShortError
+		if !errors.As(err, &tooShort) {
+			t.Fatalf("FindLTHR() error = %v, want *TooShortError", err)
+		}
+
+		want := TooShortError{Have: 18*time.Minute + 59*time.Second, Need: 20 * time.Minute}
+		if *tooShort != want {
+			t.Errorf("TooShortError = %+v, want %+v", *tooShort, want)
+		}
+	})
+
+	t.Run("too little coverage", func(t *testing.T) {
+		data := append(
+			createConstantHR(baseTime, 170, 5*60),
+			createConstantHR(baseTime.Add(20*time.Minute), 170, 5*60)...,
+		)
+		_, err := FindLTHR(data)
+
+		var coverage *CoverageError
+		if !errors.As(err, &coverage) {
+			t.Fatalf("FindLTHR() error = %v, want *CoverageError", err)
+		}
+
+		if coverage.Window != 20*time.Minute || coverage.MinCoverage != DefaultGapPolicy.MinCoverage {
+			t.Errorf("CoverageError = %+v", *coverage)
+		}
+
+		if !errors.Is(err, ErrNoValidWindow) {
+			t.Errorf("FindLTHR() error = %v, want ErrNoValidWindow", err)
+		}
+	})
+
+	t.Run("no window spans the full duration", func(t *testing.T) {
+		var data []types.HRDataPoint
+		data = append(data, createConstantHR(baseTime, 170, 5*60)...)
+		data = append(data, createConstantHR(baseTime.Add(25*time.Minute), 170, 5*60)...)
+
+		_, err := FindLTHR(data)
+		if !errors.Is(err, ErrNoValidWindow) {
+			t.Errorf("FindLTHR() error = %v, want ErrNoValidWindow", err)
+		}
+
+		if want := "could not find a valid window of 20m0s"; err == nil || err.Error() != want {
+			t.Errorf("FindLTHR() error = %v, want %q", err, want)
+		}
+
+		var coverage *CoverageError
+		if errors.As(err, &coverage) {
+			t.Errorf("FindLTHR() error = %v, want no *CoverageError", err)
+		}
+	})
+}
+
+func TestErrors_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "too short",
+			err:  &TooShortError{Have: 15 * time.Minute, Need: 20 * time.Minute},
+			want: "workout too short: need at least 20m0s",
+		},
+		{
+			name: "too short for a sub-minute window",
+			err:  &TooShortError{Have: 10 * time.Second, Need: 30 * time.Second},
+			want: "workout too short: need at least 30s",
+		},
+		{
+			name: "coverage",
+			err:  &CoverageError{Window: 8 * time.Minute, MinCoverage: 0.9},
+			want: "every 8m0s window has less than 90% heart rate coverage",
+		},
+		{
+			name: "coverage from a percentage that isn't exact in binary",
+			err:  &CoverageError{Window: 20 * time.Minute, MinCoverage: 57.0 / 100},
+			want: "every 20m0s window has less than 57% heart rate coverage",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
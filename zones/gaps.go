@@ -0,0 +1,76 @@
+package zones
+
+import (
+	"time"
+	"zone-finder/types"
+)
+
+// A stretch of a workout without heart rate data, e.g. a strap dropout or a
+// paused recording
+type Gap struct {
+	Start time.Time // timestamp of the last sample before the gap
+	End   time.Time // timestamp of the first sample after it
+}
+
+func (g Gap) Duration() time.Duration {
+	return g.End.Sub(g.Start)
+}
+
+// How gaps in heart rate data are treated when selecting a window
+type GapPolicy struct {
+	// Time between consecutive samples beyond which they're a gap
+	Threshold time.Duration
+	// Windows whose time outside gaps is a smaller fraction of their
+	// duration are refused. Negative accepts any coverage
+	MinCoverage float64
+}
+
+// The threshold is as long as a time-weighted sample is held, so devices
+// that record every few seconds during steady efforts ("smart recording")
+// aren't mistaken for dropouts
+var DefaultGapPolicy = GapPolicy{Threshold: maxSampleHold, MinCoverage: 0.9}
+
+// The policy with each zero field taken from DefaultGapPolicy
+func (p GapPolicy) WithDefaults() GapPolicy {
+	if p.Threshold == 0 {
+		p.Threshold = DefaultGapPolicy.Threshold
+	}
+	if p.MinCoverage == 0 {
+		p.MinCoverage = DefaultGapPolicy.MinCoverage
+	}
+
+	return p
+}
+
+// Find the gaps longer than threshold between consecutive data points
+func FindGaps(dataPoints []types.HRDataPoint, threshold time.Duration) []Gap {
+	sortByTimestamp(dataPoints)
+
+	var gaps []Gap
+	for i := 1; i < len(dataPoints); i++ {
+		gap := Gap{Start: dataPoints[i-1].Timestamp, End: dataPoints[i].Timestamp}
+		if gap.Duration() > threshold {
+			gaps = append(gaps, gap)
+		}
+	}
+
+	return gaps
+}
+
+// Time between data points i and i+1 when it's a gap, otherwise 0
+func (p GapPolicy) gapAfter(dataPoints []types.HRDataPoint, i int) time.Duration {
+	interval := dataPoints[i+1].Timestamp.Sub(dataPoints[i].Timestamp)
+	if interval > p.Threshold {
+		return interval
+	}
+
+	return 0
+}
+
+func (p GapPolicy) covered(span, gapTime time.Duration) bool {
+	if span <= 0 {
+		return true
+	}
+
+	return float64(span-gapTime)/float64(span) >= p.MinCoverage
+}
@@ -0,0 +1,134 @@
+package zones
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"zone-finder/types"
+)
+
+// A hard effort whose middle was lost to a 10 minute strap dropout, within
+// a steady run
+func createDropout(baseTime time.Time) []types.HRDataPoint {
+	var data []types.HRDataPoint
+	data = append(data, createConstantHR(baseTime, 150, 20*60)...)
+	data = append(data, createConstantHR(baseTime.Add(20*time.Minute), 180, 5*60)...)
+	data = append(data, createConstantHR(baseTime.Add(35*time.Minute), 180, 5*60)...)
+	data = append(data, createConstantHR(baseTime.Add(40*time.Minute), 150, 20*60)...)
+	return data
+}
+
+func TestFindGaps(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	gaps := FindGaps(createDropout(baseTime), DefaultGapPolicy.Threshold)
+	if len(gaps) != 1 {
+		t.Fatalf("FindGaps() found %d gaps, want 1", len(gaps))
+	}
+
+	want := Gap{Start: baseTime.Add(25*time.Minute - time.Second), End: baseTime.Add(35 * time.Minute)}
+	if gaps[0] != want {
+		t.Errorf("FindGaps() = %+v, want %+v", gaps[0], want)
+	}
+
+	if got := FindGaps(createConstantHR(baseTime, 150, 60), DefaultGapPolicy.Threshold); len(got) != 0 {
+		t.Errorf("FindGaps() = %+v for 1 Hz data, want none", got)
+	}
+}
+
+func TestGapPolicy_WithDefaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy GapPolicy
+		want   GapPolicy
+	}{
+		{name: "zero", policy: GapPolicy{}, want: DefaultGapPolicy},
+		{
+			name:   "threshold only",
+			policy: GapPolicy{Threshold: time.Minute},
+			want:   GapPolicy{Threshold: time.Minute, MinCoverage: DefaultGapPolicy.MinCoverage},
+		},
+		{
+			name:   "minimum coverage only",
+			policy: GapPolicy{MinCoverage: 0.5},
+			want:   GapPolicy{Threshold: DefaultGapPolicy.Threshold, MinCoverage: 0.5},
+		},
+		{
+			name:   "any coverage",
+			policy: GapPolicy{Threshold: time.Minute, MinCoverage: -1},
+			want:   GapPolicy{Threshold: time.Minute, MinCoverage: -1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.WithDefaults(); got != tt.want {
+				t.Errorf("WithDefaults() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindLTHRWithOptions_GapPolicy(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	var afterPause []types.HRDataPoint
+	afterPause = append(afterPause, createConstantHR(baseTime, 140, 25*60)...)
+	afterPause = append(afterPause, createConstantHR(baseTime.Add(40*time.Minute), 170, 25*60)...)
+
+	tests := []struct {
+		name     string
+		data     []types.HRDataPoint
+		gaps     GapPolicy
+		wantLTHR int
+		wantErr  string
+	}{
+		{
+			name:     "window across a dropout is refused",
+			data:     createDropout(baseTime),
+			gaps:     DefaultGapPolicy,
+			wantLTHR: 157,
+		},
+		{
+			name:     "window across a dropout is accepted without a minimum coverage",
+			data:     createDropout(baseTime),
+			gaps:     GapPolicy{Threshold: DefaultGapPolicy.Threshold, MinCoverage: -1},
+			wantLTHR: 180,
+		},
+		{
+			name:     "windows after a pause are considered",
+			data:     afterPause,
+			gaps:     DefaultGapPolicy,
+			wantLTHR: 170,
+		},
+		{
+			name: "no window has enough coverage",
+			data: append(
+				createConstantHR(baseTime, 170, 5*60),
+				createConstantHR(baseTime.Add(20*time.Minute), 170, 5*60)...,
+			),
+			gaps:    DefaultGapPolicy,
+			wantErr: "less than 90% heart rate coverage",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lthr, err := FindLTHRWithOptions(tt.data, Options{Gaps: tt.gaps})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("FindLTHRWithOptions() error = %v, want substring %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("FindLTHRWithOptions() error = %v", err)
+			}
+
+			if lthr < tt.wantLTHR-1 || lthr > tt.wantLTHR+1 {
+				t.Errorf("LTHR = %d, want ~%d (±1)", lthr, tt.wantLTHR)
+			}
+		})
+	}
+}
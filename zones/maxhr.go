@@ -0,0 +1,142 @@
+package zones
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"zone-finder/types"
+)
+
+// What zones are calculated as percentages of
+type Method int
+
+const (
+	// Percentages of Lactate Threshold Heart Rate
+	MethodLTHR Method = iota
+	// Percentages of maximum heart rate: 50, 60, 70, 80 and 90%
+	MethodMaxHR
+	// The Karvonen method: the same percentages of heart rate reserve, the
+	// range between resting and maximum heart rate
+	MethodHeartRateReserve
+)
+
+var methodNames = map[Method]string{
+	MethodLTHR:             "lthr",
+	MethodMaxHR:            "max-hr",
+	MethodHeartRateReserve: "hrr",
+}
+
+func (m Method) String() string {
+	if name, ok := methodNames[m]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Method(%d)", int(m))
+}
+
+// Parse a zone method name as returned by Method.String
+func ParseMethod(name string) (Method, error) {
+	for method, methodName := range methodNames {
+		if strings.EqualFold(name, methodName) {
+			return method, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown zone method %q", name)
+}
+
+// Zones 1-5 as fractions of maximum heart rate or heart rate reserve
+var maxHRZones = []SchemeZone{{Lower: 0.50}, {Lower: 0.60}, {Lower: 0.70}, {Lower: 0.80}, {Lower: 0.90}}
+
+// How long a heart rate must be held to count as the peak, so single-sample
+// spikes from optical sensors are ignored
+const peakDuration = 5 * time.Second
+
+// How long a heart rate must be held to count as resting, so brief dips
+// (e.g. a sensor losing contact) are ignored
+const restingDuration = 30 * time.Second
+
+// Find the peak heart rate of a workout: the highest heart rate held for
+// 5 seconds. Workouts recorded too sparsely for that use their highest
+// sample
+func FindMaxHR(dataPoints []types.HRDataPoint) (int, error) {
+	return findSustainedHR(dataPoints, peakDuration, func(a, b int) bool { return a > b })
+}
+
+// Estimate resting heart rate as the lowest heart rate held for 30 seconds of
+// the workout. Workouts recorded too sparsely for that use their lowest
+// sample
+func FindRestingHR(dataPoints []types.HRDataPoint) (int, error) {
+	return findSustainedHR(dataPoints, restingDuration, func(a, b int) bool { return a < b })
+}
+
+// The most extreme heart rate, as ordered by beyond, held across every
+// sample of a stretch of the given duration
+func findSustainedHR(dataPoints []types.HRDataPoint, duration time.Duration, beyond func(a, b int) bool) (int, error) {
+	if len(dataPoints) == 0 {
+		return 0, ErrNoHRData
+	}
+
+	sortByTimestamp(dataPoints)
+
+	extreme := dataPoints[0].HeartRate
+	sustained, found := 0, false
+	for i, dp := range dataPoints {
+		if beyond(dp.HeartRate, extreme) {
+			extreme = dp.HeartRate
+		}
+
+		held := dp.HeartRate
+		samples, spanned := 0, false
+		for _, next := range dataPoints[i:] {
+			if next.Timestamp.Sub(dp.Timestamp) >= duration {
+				spanned = true
+				break
+			}
+
+			if beyond(held, next.HeartRate) {
+				held = next.HeartRate
+			}
+			samples++
+		}
+
+		// a stretch cut short by the end of the workout wasn't held for the
+		// whole duration
+		if spanned && samples > 1 && (!found || beyond(held, sustained)) {
+			sustained, found = held, true
+		}
+	}
+
+	if !found {
+		return extreme, nil
+	}
+
+	return sustained, nil
+}
+
+// Calculate training zones as percentages of maximum heart rate
+func CalculateMaxHRZones(maxHR int) HeartRateZones {
+	return CalculateMaxHRZonesWithScheme(maxHR, 0, Scheme{Anchor: MethodMaxHR, Zones: maxHRZones})
+}
+
+// Calculate training zones as percentages of heart rate reserve, added to
+// resting heart rate (the Karvonen method)
+func CalculateHeartRateReserveZones(restingHR, maxHR int) HeartRateZones {
+	return CalculateMaxHRZonesWithScheme(maxHR, restingHR, Scheme{Anchor: MethodHeartRateReserve, Zones: maxHRZones})
+}
+
+// Calculate training zones using the percentages of a scheme anchored to max
+// HR or heart rate reserve. restingHR is only used for heart rate reserve
+func CalculateMaxHRZonesWithScheme(maxHR, restingHR int, scheme Scheme) HeartRateZones {
+	zones := HeartRateZones{Method: MethodMaxHR, MaxHR: maxHR, Scheme: scheme.Name}
+
+	base := 0
+	if scheme.Anchor == MethodHeartRateReserve {
+		base = restingHR
+		zones.Method = MethodHeartRateReserve
+		zones.RestingHR = restingHR
+	}
+	zones.Zones = calculateSchemeZones(scheme.Zones, base, maxHR-base, maxHR)
+
+	return zones
+}
@@ -0,0 +1,199 @@
+package zones
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+	"zone-finder/types"
+)
+
+func TestFindMaxHR(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		data      []types.HRDataPoint
+		wantMaxHR int
+	}{
+		{
+			name: "sprint finish",
+			data: append(
+				createConstantHR(baseTime, 160, 10*60),
+				createHRData(baseTime.Add(10*time.Minute), []int{180, 185, 188, 191, 190, 189, 190, 187, 175})...,
+			),
+			wantMaxHR: 188,
+		},
+		{
+			name:      "single-sample spike is ignored",
+			data:      createHRData(baseTime, []int{150, 152, 151, 214, 150, 151, 152, 150, 151}),
+			wantMaxHR: 150,
+		},
+		{
+			name: "spike at the end of the workout is ignored",
+			data: append(
+				createConstantHR(baseTime, 150, 10*60),
+				createHRData(baseTime.Add(10*time.Minute), []int{210, 210})...,
+			),
+			wantMaxHR: 150,
+		},
+		{
+			name: "sparse recording uses the highest sample",
+			data: []types.HRDataPoint{
+				{Timestamp: baseTime, HeartRate: 150},
+				{Timestamp: baseTime.Add(10 * time.Second), HeartRate: 176},
+				{Timestamp: baseTime.Add(20 * time.Second), HeartRate: 168},
+			},
+			wantMaxHR: 176,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxHR, err := FindMaxHR(tt.data)
+			if err != nil {
+				t.Fatalf("FindMaxHR() error = %v", err)
+			}
+
+			if maxHR != tt.wantMaxHR {
+				t.Errorf("FindMaxHR() = %d, want %d", maxHR, tt.wantMaxHR)
+			}
+		})
+	}
+
+	if _, err := FindMaxHR(nil); !errors.Is(err, ErrNoHRData) {
+		t.Errorf("FindMaxHR() error = %v, want ErrNoHRData", err)
+	}
+}
+
+func TestCalculateMaxHRZones(t *testing.T) {
+	zones := CalculateMaxHRZones(190)
+
+	want := []Zone{
+		{Number: 1, Min: 95, Max: 113},
+		{Number: 2, Min: 114, Max: 132},
+		{Number: 3, Min: 133, Max: 151},
+		{Number: 4, Min: 152, Max: 170},
+		{Number: 5, Min: 171, Max: 190},
+	}
+	if !slices.Equal(zones.Zones, want) {
+		t.Errorf("Zones = %+v, want %+v", zones.Zones, want)
+	}
+
+	if zones.Method != MethodMaxHR || zones.MaxHR != 190 || zones.LTHR != 0 {
+		t.Errorf("CalculateMaxHRZones() = %+v, want max-hr zones for 190 bpm", zones)
+	}
+}
+
+func TestFindRestingHR(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	var data []types.HRDataPoint
+	data = append(data, createConstantHR(baseTime, 72, 2*60)...)
+	data = append(data, createConstantHR(baseTime.Add(2*time.Minute), 140, 10*60)...)
+	// the strap losing contact for a moment
+	data[30].HeartRate = 41
+
+	restingHR, err := FindRestingHR(data)
+	if err != nil {
+		t.Fatalf("FindRestingHR() error = %v", err)
+	}
+
+	if restingHR != 72 {
+		t.Errorf("FindRestingHR() = %d, want 72", restingHR)
+	}
+
+	// a dip in the last seconds of the workout
+	ending := append(createConstantHR(baseTime, 72, 2*60), createHRData(baseTime.Add(2*time.Minute), []int{45, 45})...)
+	if restingHR, _ := FindRestingHR(ending); restingHR != 72 {
+		t.Errorf("FindRestingHR() = %d with a dip at the end, want 72", restingHR)
+	}
+
+	sparse := []types.HRDataPoint{
+		{Timestamp: baseTime, HeartRate: 80},
+		{Timestamp: baseTime.Add(time.Minute), HeartRate: 64},
+		{Timestamp: baseTime.Add(2 * time.Minute), HeartRate: 120},
+	}
+	if restingHR, _ := FindRestingHR(sparse); restingHR != 64 {
+		t.Errorf("FindRestingHR() = %d for sparse data, want the lowest sample 64", restingHR)
+	}
+}
+
+func TestCalculateHeartRateReserveZones(t *testing.T) {
+	zones := CalculateHeartRateReserveZones(50, 190)
+
+	want := []Zone{
+		{Number: 1, Min: 120, Max: 133},
+		{Number: 2, Min: 134, Max: 147},
+		{Number: 3, Min: 148, Max: 161},
+		{Number: 4, Min: 162, Max: 175},
+		{Number: 5, Min: 176, Max: 190},
+	}
+	if !slices.Equal(zones.Zones, want) {
+		t.Errorf("Zones = %+v, want %+v", zones.Zones, want)
+	}
+
+	if zones.Method != MethodHeartRateReserve || zones.MaxHR != 190 || zones.RestingHR != 50 {
+		t.Errorf("CalculateHeartRateReserveZones() = %+v, want hrr zones for 50-190 bpm", zones)
+	}
+}
+
+func TestCalculateZonesFromHRDataWithOptions_MaxHR(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	data := append(
+		createConstantHR(baseTime, 150, 5*60),
+		createConstantHR(baseTime.Add(5*time.Minute), 186, 30)...,
+	)
+
+	tests := []struct {
+		name              string
+		opts              Options
+		wantMaxHR         int
+		wantRestingSource RestingHRSource
+		wantErr           bool
+	}{
+		{name: "detected from the workout", opts: Options{Method: MethodMaxHR}, wantMaxHR: 186},
+		{name: "given", opts: Options{Method: MethodMaxHR, MaxHR: 195}, wantMaxHR: 195},
+		{name: "negative", opts: Options{Method: MethodMaxHR, MaxHR: -1}, wantErr: true},
+		{name: "heart rate reserve", opts: Options{Method: MethodHeartRateReserve, RestingHR: 48}, wantMaxHR: 186, wantRestingSource: RestingHRGiven},
+		{name: "estimated resting HR", opts: Options{Method: MethodHeartRateReserve}, wantMaxHR: 186, wantRestingSource: RestingHREstimated},
+		{name: "resting above max", opts: Options{Method: MethodHeartRateReserve, RestingHR: 200}, wantErr: true},
+		{name: "unknown method", opts: Options{Method: Method(42)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// shorter than a 20-minute window, which max HR zones don't need
+			zones, err := CalculateZonesFromHRDataWithOptions(data, tt.opts)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CalculateZonesFromHRDataWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && zones.MaxHR != tt.wantMaxHR {
+				t.Errorf("MaxHR = %d, want %d", zones.MaxHR, tt.wantMaxHR)
+			}
+
+			if zones.RestingHRSource != tt.wantRestingSource {
+				t.Errorf("RestingHRSource = %v, want %v", zones.RestingHRSource, tt.wantRestingSource)
+			}
+		})
+	}
+}
+
+func TestParseMethod(t *testing.T) {
+	for _, method := range []Method{MethodLTHR, MethodMaxHR, MethodHeartRateReserve} {
+		parsed, err := ParseMethod(method.String())
+		if err != nil {
+			t.Errorf("ParseMethod(%q) error = %v", method, err)
+		}
+
+		if parsed != method {
+			t.Errorf("ParseMethod(%q) = %v, want %v", method, parsed, method)
+		}
+	}
+
+	if _, err := ParseMethod("vo2"); err == nil {
+		t.Error("ParseMethod() expected error for unknown method")
+	}
+}
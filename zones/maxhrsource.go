@@ -0,0 +1,143 @@
+package zones
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"zone-finder/types"
+)
+
+// Where maximum heart rate, which ends the top zone, comes from
+type MaxHRSource int
+
+const (
+	// Not chosen: LTHR zones are open-ended, and max-hr and heart rate
+	// reserve zones use the observed max
+	MaxHRUnknown MaxHRSource = iota
+	// The peak heart rate of the workout, as found by FindMaxHR
+	MaxHRObserved
+	// Options.MaxHR, e.g. from a lab test
+	MaxHRGiven
+	// 208 - 0.7 × age (Tanaka, Monahan and Seals, 2001)
+	MaxHRTanaka
+	// 206 - 0.88 × age, derived from women (Gulati et al., 2010)
+	MaxHRGulati
+	// 220 - age (Fox, Naughton and Haskell, 1971)
+	MaxHRFox
+)
+
+var maxHRSourceNames = map[MaxHRSource]string{
+	MaxHRUnknown:  "unknown",
+	MaxHRObserved: "observed",
+	MaxHRGiven:    "given",
+	MaxHRTanaka:   "tanaka",
+	MaxHRGulati:   "gulati",
+	MaxHRFox:      "fox",
+}
+
+func (s MaxHRSource) String() string {
+	if name, ok := maxHRSourceNames[s]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("MaxHRSource(%d)", int(s))
+}
+
+// Parse a max HR source name as returned by MaxHRSource.String
+func ParseMaxHRSource(name string) (MaxHRSource, error) {
+	for source, sourceName := range maxHRSourceNames {
+		if strings.EqualFold(name, sourceName) {
+			return source, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown max HR source %q", name)
+}
+
+// Ages the age formulas are taken to hold for
+const (
+	MinAge = 10
+	MaxAge = 100
+)
+
+// Whether max HR is estimated from age
+func (s MaxHRSource) IsFormula() bool {
+	return s == MaxHRTanaka || s == MaxHRGulati || s == MaxHRFox
+}
+
+// Estimate max HR from age with one of the age formulas
+func EstimateMaxHR(age int, formula MaxHRSource) (int, error) {
+	if age < MinAge || age > MaxAge {
+		return 0, fmt.Errorf("invalid age %d: must be between %d and %d", age, MinAge, MaxAge)
+	}
+
+	switch formula {
+	case MaxHRTanaka:
+		return int(math.Round(208 - 0.7*float64(age))), nil
+	case MaxHRGulati:
+		return int(math.Round(206 - 0.88*float64(age))), nil
+	case MaxHRFox:
+		return 220 - age, nil
+	default:
+		return 0, fmt.Errorf("%v is not an age formula", formula)
+	}
+}
+
+// Max HR as chosen by opts: given, estimated from age, or found from the
+// workout. Returns MaxHRUnknown when opts doesn't choose
+func FindMaxHRWithOptions(dataPoints []types.HRDataPoint, opts Options) (int, MaxHRSource, error) {
+	source := opts.MaxHRSource
+	if opts.MaxHR != 0 {
+		source = MaxHRGiven
+	}
+
+	switch {
+	case source == MaxHRUnknown:
+		return 0, MaxHRUnknown, nil
+	case source == MaxHRObserved:
+		maxHR, err := FindMaxHR(dataPoints)
+		return maxHR, source, err
+	case source == MaxHRGiven:
+		if opts.MaxHR <= 0 {
+			return 0, source, fmt.Errorf("invalid max HR %d: must be positive", opts.MaxHR)
+		}
+		return opts.MaxHR, source, nil
+	case source.IsFormula():
+		maxHR, err := EstimateMaxHR(opts.Age, source)
+		return maxHR, source, err
+	default:
+		return 0, source, fmt.Errorf("unknown max HR source %v", source)
+	}
+}
+
+// End the top zone at the max HR chosen by opts, found from dataPoints when
+// observed. Zones starting above max HR are moved to AboveMaxHR. Zones are
+// returned unchanged when opts doesn't choose a max HR. A given or estimated
+// max HR below LTHR is an error, as the athlete has held more than it
+func CapZones(zones HeartRateZones, dataPoints []types.HRDataPoint, opts Options) (HeartRateZones, error) {
+	maxHR, source, err := FindMaxHRWithOptions(dataPoints, opts)
+	if err != nil || source == MaxHRUnknown {
+		return zones, err
+	}
+	if source != MaxHRObserved && maxHR < zones.LTHR {
+		return HeartRateZones{}, fmt.Errorf("max HR %d (%v) is below LTHR %d", maxHR, source, zones.LTHR)
+	}
+
+	below := len(zones.Zones)
+	for below > 0 && zones.Zones[below-1].Min > maxHR {
+		below--
+	}
+	if below == 0 {
+		return HeartRateZones{}, fmt.Errorf("max HR %d (%v) is below every zone", maxHR, source)
+	}
+
+	zones.AboveMaxHR = slices.Clone(zones.Zones[below:])
+	zones.Zones = slices.Clone(zones.Zones[:below])
+	top := &zones.Zones[below-1]
+	top.Max = maxHR
+	zones.MaxHR = maxHR
+	zones.MaxHRSource = source
+
+	return zones, nil
+}
@@ -0,0 +1,187 @@
+package zones
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEstimateMaxHR(t *testing.T) {
+	tests := []struct {
+		formula MaxHRSource
+		age     int
+		want    int
+	}{
+		{formula: MaxHRTanaka, age: 40, want: 180},
+		{formula: MaxHRTanaka, age: 25, want: 191},
+		{formula: MaxHRGulati, age: 40, want: 171},
+		{formula: MaxHRFox, age: 40, want: 180},
+	}
+
+	for _, tt := range tests {
+		got, err := EstimateMaxHR(tt.age, tt.formula)
+		if err != nil {
+			t.Fatalf("EstimateMaxHR(%d, %v) error = %v", tt.age, tt.formula, err)
+		}
+
+		if got != tt.want {
+			t.Errorf("EstimateMaxHR(%d, %v) = %d, want %d", tt.age, tt.formula, got, tt.want)
+		}
+	}
+
+	if _, err := EstimateMaxHR(0, MaxHRTanaka); err == nil {
+		t.Error("EstimateMaxHR() expected error without an age")
+	}
+
+	if _, err := EstimateMaxHR(300, MaxHRFox); err == nil {
+		t.Error("EstimateMaxHR() expected error for an implausible age")
+	}
+
+	if _, err := EstimateMaxHR(40, MaxHRObserved); err == nil {
+		t.Error("EstimateMaxHR() expected error for a source that isn't a formula")
+	}
+}
+
+func TestCapZones(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	data := createTimeTrial(baseTime)
+	lthrZones := CalculateZones(177)
+
+	tests := []struct {
+		name       string
+		opts       Options
+		wantMax    int
+		wantSource MaxHRSource
+		wantAbove  []string
+		wantErr    string
+	}{
+		{
+			name:       "unknown leaves the top zone open-ended",
+			opts:       Options{},
+			wantMax:    0,
+			wantSource: MaxHRUnknown,
+		},
+		{
+			name:       "observed",
+			opts:       Options{MaxHRSource: MaxHRObserved},
+			wantMax:    185,
+			wantSource: MaxHRObserved,
+		},
+		{
+			name:       "given",
+			opts:       Options{MaxHR: 196},
+			wantMax:    196,
+			wantSource: MaxHRGiven,
+		},
+		{
+			name:       "given wins over a formula",
+			opts:       Options{MaxHR: 196, MaxHRSource: MaxHRFox, Age: 40},
+			wantMax:    196,
+			wantSource: MaxHRGiven,
+		},
+		{
+			name:       "tanaka",
+			opts:       Options{MaxHRSource: MaxHRTanaka, Age: 25},
+			wantMax:    191,
+			wantSource: MaxHRTanaka,
+		},
+		{
+			name:    "formula without an age",
+			opts:    Options{MaxHRSource: MaxHRGulati},
+			wantErr: "invalid age 0",
+		},
+		{
+			name:       "max below the top zone leaves it out",
+			opts:       Options{MaxHR: 177},
+			wantMax:    177,
+			wantSource: MaxHRGiven,
+			wantAbove:  []string{"5"},
+		},
+		{
+			name:    "given max below LTHR",
+			opts:    Options{MaxHR: 150},
+			wantErr: "max HR 150 (given) is below LTHR 177",
+		},
+		{
+			name:    "estimated max below LTHR",
+			opts:    Options{MaxHRSource: MaxHRFox, Age: 50},
+			wantErr: "max HR 170 (fox) is below LTHR 177",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := CapZones(lthrZones, data, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("CapZones() error = %v, want substring %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("CapZones() error = %v", err)
+			}
+
+			if top := result.Zones[len(result.Zones)-1]; top.Max != tt.wantMax {
+				t.Errorf("top zone = %+v, want it to end at %d", top, tt.wantMax)
+			}
+
+			if result.MaxHRSource != tt.wantSource {
+				t.Errorf("MaxHRSource = %v, want %v", result.MaxHRSource, tt.wantSource)
+			}
+
+			var above []string
+			for _, zone := range result.AboveMaxHR {
+				above = append(above, zone.Label())
+			}
+			if !slices.Equal(above, tt.wantAbove) {
+				t.Errorf("AboveMaxHR = %v, want zones %v", above, tt.wantAbove)
+			}
+		})
+	}
+
+	if lthrZones.Zones[4].Max != 0 {
+		t.Error("CapZones() should not modify its input")
+	}
+}
+
+func TestCalculateZonesFromHRDataWithOptions_MaxHRSource(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	lthrZones, err := CalculateZonesFromHRDataWithOptions(createTimeTrial(baseTime), Options{MaxHRSource: MaxHRObserved})
+	if err != nil {
+		t.Fatalf("CalculateZonesFromHRDataWithOptions() error = %v", err)
+	}
+
+	if lthrZones.MaxHR != 185 || lthrZones.Zones[4].Max != 185 || lthrZones.Window == nil {
+		t.Errorf("CalculateZonesFromHRDataWithOptions() = %+v, want LTHR zones ending at the observed 185 bpm", lthrZones)
+	}
+
+	maxHRZones, err := CalculateZonesFromHRDataWithOptions(createTimeTrial(baseTime), Options{Method: MethodMaxHR, MaxHRSource: MaxHRTanaka, Age: 40})
+	if err != nil {
+		t.Fatalf("CalculateZonesFromHRDataWithOptions() error = %v", err)
+	}
+
+	if maxHRZones.MaxHR != 180 || maxHRZones.MaxHRSource != MaxHRTanaka {
+		t.Errorf("CalculateZonesFromHRDataWithOptions() = %+v, want max-hr zones from Tanaka's 180 bpm", maxHRZones)
+	}
+}
+
+func TestParseMaxHRSource(t *testing.T) {
+	for source := range maxHRSourceNames {
+		parsed, err := ParseMaxHRSource(source.String())
+		if err != nil {
+			t.Errorf("ParseMaxHRSource(%q) error = %v", source, err)
+		}
+
+		if parsed != source {
+			t.Errorf("ParseMaxHRSource(%q) = %v, want %v", source, parsed, source)
+		}
+	}
+
+	if _, err := ParseMaxHRSource("astrand"); err == nil {
+		t.Error("ParseMaxHRSource() expected error for unknown source")
+	}
+}
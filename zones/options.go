@@ -0,0 +1,173 @@
+package zones
+
+import (
+	"fmt"
+	"time"
+	"zone-finder/types"
+)
+
+// Settings for finding LTHR and calculating zones. Zero values select the
+// defaults used by CalculateZonesFromHRData
+type Options struct {
+	// Duration of the threshold effort, 20 minutes by default. The Friel
+	// protocol always uses its 30-minute time trial
+	Window time.Duration
+	// How much shorter than Window the recorded data may span, 2 seconds
+	// by default
+	Tolerance time.Duration
+	Protocol  Protocol
+	Averaging Averaging
+	// DefaultGapPolicy's fields where zero
+	Gaps GapPolicy
+	// Zones use the sport's default percentages of LTHR when set
+	Sport types.Sport
+	// Zones use the scheme's percentages instead of the default five zones
+	// when set, and its anchor instead of Method
+	Scheme *Scheme
+	// Percentages of LTHR by default
+	Method Method
+	// Maximum heart rate, ending the top zone. Chooses MaxHRGiven when set
+	MaxHR int
+	// Where max HR comes from when MaxHR isn't set. LTHR zones are
+	// open-ended, and other methods use the workout's peak, when unknown
+	MaxHRSource MaxHRSource
+	// Age in years, for the age formulas
+	Age int
+	// Resting heart rate for MethodHeartRateReserve, estimated from the
+	// workout when 0
+	RestingHR int
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Window:    windowDuration,
+		Tolerance: windowTolerance,
+		Protocol:  ProtocolBestWindow,
+		Averaging: AveragingSampleMean,
+		Gaps:      DefaultGapPolicy,
+	}
+}
+
+func (o Options) withDefaults() Options {
+	defaults := DefaultOptions()
+
+	if o.Window == 0 {
+		o.Window = defaults.Window
+	}
+	if o.Tolerance == 0 {
+		o.Tolerance = defaults.Tolerance
+	}
+	o.Gaps = o.Gaps.WithDefaults()
+
+	return o
+}
+
+func (o Options) validate() error {
+	if o.Window < 0 {
+		return fmt.Errorf("invalid window %v: must be positive", o.Window)
+	}
+	if o.Tolerance < 0 {
+		return fmt.Errorf("invalid tolerance %v: must not be negative", o.Tolerance)
+	}
+	// the tolerance is usually the default, so the window is what to change
+	if o.Window <= o.Tolerance {
+		return fmt.Errorf("invalid window %v: must be longer than the %v tolerance", o.Window, o.Tolerance)
+	}
+
+	return nil
+}
+
+// Calculate training zones from HR data points, using the LTHR method unless
+// configured otherwise by opts
+func CalculateZonesFromHRDataWithOptions(dataPoints []types.HRDataPoint, opts Options) (HeartRateZones, error) {
+	if opts.Scheme != nil {
+		opts.Method = opts.Scheme.Anchor
+	}
+
+	switch opts.Method {
+	case MethodLTHR:
+		return calculateLTHRZonesFromHRData(dataPoints, opts)
+	case MethodMaxHR, MethodHeartRateReserve:
+		return calculateMaxHRZonesFromHRData(dataPoints, opts)
+	default:
+		return HeartRateZones{}, fmt.Errorf("unknown zone method %v", opts.Method)
+	}
+}
+
+func calculateLTHRZonesFromHRData(dataPoints []types.HRDataPoint, opts Options) (HeartRateZones, error) {
+	lactateThreshold, window, err := FindLTHRWithWindow(dataPoints, opts)
+	if err != nil {
+		return HeartRateZones{}, err
+	}
+
+	zones, err := CapZones(CalculateZonesWithOptions(lactateThreshold, opts), dataPoints, opts)
+	if err != nil {
+		return HeartRateZones{}, err
+	}
+	zones.Window = &window
+
+	return zones, nil
+}
+
+// Calculate training zones from a known LTHR, using the scheme and sport
+// from opts
+func CalculateZonesWithOptions(lthr int, opts Options) HeartRateZones {
+	switch {
+	case opts.Scheme != nil:
+		zones := CalculateZonesWithScheme(lthr, *opts.Scheme)
+		zones.Sport = opts.Sport
+		return zones
+	case opts.Sport != "":
+		return CalculateZonesForSport(lthr, opts.Sport)
+	default:
+		return CalculateZones(lthr)
+	}
+}
+
+// Zones from max HR, and resting HR for heart rate reserve zones, each found
+// from the workout unless given
+func calculateMaxHRZonesFromHRData(dataPoints []types.HRDataPoint, opts Options) (HeartRateZones, error) {
+	if opts.MaxHRSource == MaxHRUnknown {
+		opts.MaxHRSource = MaxHRObserved
+	}
+
+	maxHR, source, err := FindMaxHRWithOptions(dataPoints, opts)
+	if err != nil {
+		return HeartRateZones{}, err
+	}
+
+	restingHR, restingSource := 0, RestingHRUnknown
+	if opts.Method == MethodHeartRateReserve {
+		if restingHR, restingSource, err = givenOrEstimatedRestingHR(opts.RestingHR, dataPoints); err != nil {
+			return HeartRateZones{}, err
+		}
+
+		if restingHR >= maxHR {
+			return HeartRateZones{}, fmt.Errorf("resting HR %d must be below max HR %d", restingHR, maxHR)
+		}
+	}
+
+	scheme := Scheme{Anchor: opts.Method, Zones: maxHRZones}
+	if opts.Scheme != nil {
+		scheme = *opts.Scheme
+	}
+
+	zones := CalculateMaxHRZonesWithScheme(maxHR, restingHR, scheme)
+	zones.MaxHRSource = source
+	zones.RestingHRSource = restingSource
+	zones.Sport = opts.Sport
+
+	return zones, nil
+}
+
+func givenOrEstimatedRestingHR(given int, dataPoints []types.HRDataPoint) (int, RestingHRSource, error) {
+	if given < 0 {
+		return 0, RestingHRUnknown, fmt.Errorf("invalid resting HR %d: must be positive", given)
+	}
+	if given > 0 {
+		return given, RestingHRGiven, nil
+	}
+
+	restingHR, err := FindRestingHR(dataPoints)
+	return restingHR, RestingHREstimated, err
+}
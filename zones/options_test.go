@@ -0,0 +1,101 @@
+package zones
+
+import (
+	"testing"
+	"time"
+	"zone-finder/types"
+)
+
+// 5 minute warmup, an 8 minute field test, then a 2 minute cooldown
+func createEightMinuteTest(baseTime time.Time) []types.HRDataPoint {
+	var data []types.HRDataPoint
+	data = append(data, createConstantHR(baseTime, 120, 5*60)...)
+	data = append(data, createConstantHR(baseTime.Add(5*time.Minute), 182, 8*60)...)
+	data = append(data, createConstantHR(baseTime.Add(13*time.Minute), 110, 2*60)...)
+	return data
+}
+
+func TestCalculateZonesFromHRDataWithOptions(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		data      []types.HRDataPoint
+		opts      Options
+		wantLTHR  int
+		wantSport types.Sport
+		wantErr   bool
+	}{
+		{
+			name:     "zero options match CalculateZonesFromHRData",
+			data:     createTimeTrial(baseTime),
+			opts:     Options{},
+			wantLTHR: 177,
+		},
+		{
+			name:     "8 minute field test",
+			data:     createEightMinuteTest(baseTime),
+			opts:     Options{Window: 8 * time.Minute},
+			wantLTHR: 182,
+		},
+		{
+			name:    "8 minute field test is too short for the default window",
+			data:    createEightMinuteTest(baseTime),
+			opts:    Options{},
+			wantErr: true,
+		},
+		{
+			name:     "30 minute window",
+			data:     createTimeTrial(baseTime),
+			opts:     Options{Window: 30 * time.Minute},
+			wantLTHR: 175,
+		},
+		{
+			name:      "sport-specific zones",
+			data:      createTimeTrial(baseTime),
+			opts:      Options{Sport: types.SportCycling},
+			wantLTHR:  177,
+			wantSport: types.SportCycling,
+		},
+		{
+			name:    "tolerance as long as the window",
+			data:    createTimeTrial(baseTime),
+			opts:    Options{Window: time.Minute, Tolerance: time.Minute},
+			wantErr: true,
+		},
+		{
+			name:    "window no longer than the default tolerance",
+			data:    createTimeTrial(baseTime),
+			opts:    Options{Window: time.Second},
+			wantErr: true,
+		},
+		{
+			name:    "negative window",
+			data:    createTimeTrial(baseTime),
+			opts:    Options{Window: -time.Minute},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zones, err := CalculateZonesFromHRDataWithOptions(tt.data, tt.opts)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CalculateZonesFromHRDataWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if zones.LTHR < tt.wantLTHR-1 || zones.LTHR > tt.wantLTHR+1 {
+				t.Errorf("LTHR = %d, want ~%d (±1)", zones.LTHR, tt.wantLTHR)
+			}
+
+			if zones.Sport != tt.wantSport {
+				t.Errorf("Sport = %q, want %q", zones.Sport, tt.wantSport)
+			}
+		})
+	}
+}
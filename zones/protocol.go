@@ -0,0 +1,113 @@
+package zones
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"zone-finder/types"
+)
+
+// A field test protocol for deriving LTHR from a workout
+type Protocol int
+
+const (
+	// Average of the 20-minute window with the highest average heart rate
+	ProtocolBestWindow Protocol = iota
+	// Joe Friel's protocol: average of the last 20 minutes of a 30-minute
+	// solo time trial
+	ProtocolFriel
+	// 95% of the average of a 20-minute all-out effort
+	ProtocolTwentyMinute
+)
+
+const (
+	frielTimeTrialDuration = 30 * time.Minute
+	twentyMinuteFactor     = 0.95
+)
+
+var protocolNames = map[Protocol]string{
+	ProtocolBestWindow:   "best-window",
+	ProtocolFriel:        "friel",
+	ProtocolTwentyMinute: "95-percent",
+}
+
+func (p Protocol) String() string {
+	if name, ok := protocolNames[p]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Protocol(%d)", int(p))
+}
+
+// Parse a protocol name as returned by Protocol.String
+func ParseProtocol(name string) (Protocol, error) {
+	for protocol, protocolName := range protocolNames {
+		if strings.EqualFold(name, protocolName) {
+			return protocol, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown LTHR protocol %q", name)
+}
+
+// Find the LTHR from a workout using the given field test protocol
+func FindLTHRWithProtocol(dataPoints []types.HRDataPoint, protocol Protocol) (int, error) {
+	return FindLTHRWithOptions(dataPoints, Options{Protocol: protocol})
+}
+
+// Find the LTHR from a workout as configured by opts, e.g. its protocol,
+// averaging and gap policy
+func FindLTHRWithOptions(dataPoints []types.HRDataPoint, opts Options) (int, error) {
+	lthr, _, err := FindLTHRWithWindow(dataPoints, opts)
+	return lthr, err
+}
+
+// Find the LTHR from a workout as configured by opts, along with the window
+// it was calculated from
+func FindLTHRWithWindow(dataPoints []types.HRDataPoint, opts Options) (int, Window, error) {
+	opts = opts.withDefaults()
+	if err := opts.validate(); err != nil {
+		return 0, Window{}, err
+	}
+
+	var window []types.HRDataPoint
+	var err error
+	switch opts.Protocol {
+	case ProtocolBestWindow, ProtocolTwentyMinute:
+		window, err = findBestWindow(dataPoints, opts.Window, opts)
+	case ProtocolFriel:
+		var timeTrial []types.HRDataPoint
+		timeTrial, err = findBestWindow(dataPoints, frielTimeTrialDuration, opts)
+		if err == nil {
+			window = lastWindow(timeTrial, windowDuration)
+		}
+	default:
+		return 0, Window{}, fmt.Errorf("unknown LTHR protocol %v", opts.Protocol)
+	}
+	if err != nil {
+		return 0, Window{}, err
+	}
+
+	// findBestWindow sorted the data points
+	selected := NewWindow(window, dataPoints[0].Timestamp, opts.Averaging)
+
+	if opts.Protocol == ProtocolTwentyMinute {
+		return int(math.Round(selected.AvgHR * twentyMinuteFactor)), selected, nil
+	}
+
+	return int(math.Round(selected.AvgHR)), selected, nil
+}
+
+// The trailing part of a sorted window covering the given duration
+func lastWindow(dataPoints []types.HRDataPoint, duration time.Duration) []types.HRDataPoint {
+	start := dataPoints[len(dataPoints)-1].Timestamp.Add(-duration)
+
+	for i, dp := range dataPoints {
+		if !dp.Timestamp.Before(start) {
+			return dataPoints[i:]
+		}
+	}
+
+	return dataPoints
+}
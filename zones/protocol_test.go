@@ -0,0 +1,95 @@
+package zones
+
+import (
+	"testing"
+	"time"
+	"zone-finder/types"
+)
+
+// 10 minute warmup, a 30 minute time trial started too hard, then a cooldown
+func createTimeTrial(baseTime time.Time) []types.HRDataPoint {
+	var data []types.HRDataPoint
+	data = append(data, createConstantHR(baseTime, 120, 10*60)...)
+	data = append(data, createConstantHR(baseTime.Add(10*time.Minute), 185, 10*60)...)
+	data = append(data, createConstantHR(baseTime.Add(20*time.Minute), 170, 20*60)...)
+	data = append(data, createConstantHR(baseTime.Add(40*time.Minute), 110, 5*60)...)
+	return data
+}
+
+func TestFindLTHRWithProtocol(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		data     []types.HRDataPoint
+		protocol Protocol
+		wantLTHR int
+		wantErr  bool
+	}{
+		{
+			name:     "best window includes the hard start",
+			data:     createTimeTrial(baseTime),
+			protocol: ProtocolBestWindow,
+			wantLTHR: 177,
+		},
+		{
+			name:     "friel uses the last 20 minutes of the time trial",
+			data:     createTimeTrial(baseTime),
+			protocol: ProtocolFriel,
+			wantLTHR: 170,
+		},
+		{
+			name:     "95 percent of the best 20 minutes",
+			data:     createTimeTrial(baseTime),
+			protocol: ProtocolTwentyMinute,
+			wantLTHR: 169,
+		},
+		{
+			name:     "friel needs 30 minutes",
+			data:     createConstantHR(baseTime, 170, 25*60),
+			protocol: ProtocolFriel,
+			wantErr:  true,
+		},
+		{
+			name:     "unknown protocol",
+			data:     createConstantHR(baseTime, 170, 25*60),
+			protocol: Protocol(42),
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lthr, err := FindLTHRWithProtocol(tt.data, tt.protocol)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FindLTHRWithProtocol() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if lthr < tt.wantLTHR-1 || lthr > tt.wantLTHR+1 {
+				t.Errorf("LTHR = %d, want ~%d (±1)", lthr, tt.wantLTHR)
+			}
+		})
+	}
+}
+
+func TestParseProtocol(t *testing.T) {
+	for _, protocol := range []Protocol{ProtocolBestWindow, ProtocolFriel, ProtocolTwentyMinute} {
+		parsed, err := ParseProtocol(protocol.String())
+		if err != nil {
+			t.Errorf("ParseProtocol(%q) error = %v", protocol, err)
+		}
+
+		if parsed != protocol {
+			t.Errorf("ParseProtocol(%q) = %v, want %v", protocol, parsed, protocol)
+		}
+	}
+
+	if _, err := ParseProtocol("ramp-test"); err == nil {
+		t.Error("ParseProtocol() expected error for unknown protocol")
+	}
+}
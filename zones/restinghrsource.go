@@ -0,0 +1,30 @@
+package zones
+
+import "fmt"
+
+// Where resting heart rate, the base of heart rate reserve zones, comes from
+type RestingHRSource int
+
+const (
+	// Zones aren't based on heart rate reserve
+	RestingHRUnknown RestingHRSource = iota
+	// The lowest heart rate held during the workout, as found by
+	// FindRestingHR. Usually well above true resting heart rate
+	RestingHREstimated
+	// Options.RestingHR, e.g. measured on waking
+	RestingHRGiven
+)
+
+var restingHRSourceNames = map[RestingHRSource]string{
+	RestingHRUnknown:   "unknown",
+	RestingHREstimated: "estimated",
+	RestingHRGiven:     "given",
+}
+
+func (s RestingHRSource) String() string {
+	if name, ok := restingHRSourceNames[s]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("RestingHRSource(%d)", int(s))
+}
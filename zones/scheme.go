@@ -0,0 +1,135 @@
+package zones
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"zone-finder/types"
+)
+
+// A zone model with any number of zones, each starting at a percentage of
+// its anchor and ending where the next one starts. The top zone runs to max
+// HR
+type Scheme struct {
+	Name string
+	// What the percentages are of: LTHR by default, max HR or heart rate
+	// reserve
+	Anchor Method
+	Zones  []SchemeZone
+}
+
+type SchemeZone struct {
+	Name  string  // e.g. "5a"
+	Lower float64 // fraction of the anchor the zone starts at
+}
+
+// Joe Friel's seven LTHR zones. Runners and cyclists share zone 5 but start
+// the lower zones at different percentages
+var (
+	frielRun = Scheme{Name: "friel-run", Zones: []SchemeZone{
+		{Name: "1", Lower: 0},
+		{Name: "2", Lower: 0.85},
+		{Name: "3", Lower: 0.90},
+		{Name: "4", Lower: 0.95},
+		{Name: "5a", Lower: 1.00},
+		{Name: "5b", Lower: 1.03},
+		{Name: "5c", Lower: 1.07},
+	}}
+	frielBike = Scheme{Name: "friel-bike", Zones: []SchemeZone{
+		{Name: "1", Lower: 0},
+		{Name: "2", Lower: 0.81},
+		{Name: "3", Lower: 0.90},
+		{Name: "4", Lower: 0.94},
+		{Name: "5a", Lower: 1.00},
+		{Name: "5b", Lower: 1.03},
+		{Name: "5c", Lower: 1.07},
+	}}
+)
+
+var builtinSchemes = map[string]Scheme{
+	frielRun.Name:  frielRun,
+	frielBike.Name: frielBike,
+}
+
+// Find a built-in scheme by name. "friel" selects the bike zones for cycling
+// and the run zones for every other sport
+func LookupScheme(name string, sport types.Sport) (Scheme, error) {
+	if name == "friel" {
+		if sport == types.SportCycling {
+			return frielBike, nil
+		}
+		return frielRun, nil
+	}
+
+	scheme, ok := builtinSchemes[name]
+	if !ok {
+		return Scheme{}, fmt.Errorf("unknown zone scheme %q", name)
+	}
+
+	return scheme, nil
+}
+
+// Check a scheme has a name, a known anchor and zones starting at 0% or
+// above in increasing order
+func (s Scheme) Validate() error {
+	if s.Name == "" {
+		return errors.New("zone scheme has no name")
+	}
+	if _, ok := methodNames[s.Anchor]; !ok {
+		return fmt.Errorf("zone scheme %q has unknown anchor %v", s.Name, s.Anchor)
+	}
+	if len(s.Zones) == 0 {
+		return fmt.Errorf("zone scheme %q has no zones", s.Name)
+	}
+	if s.Zones[0].Lower < 0 {
+		return fmt.Errorf("zone %s of scheme %q starts below 0%%", s.zoneLabel(0), s.Name)
+	}
+
+	for i := 1; i < len(s.Zones); i++ {
+		if s.Zones[i].Lower <= s.Zones[i-1].Lower {
+			return fmt.Errorf("zone %s of scheme %q starts at %s, overlapping zone %s which starts at %s",
+				s.zoneLabel(i), s.Name, formatPercent(s.Zones[i].Lower), s.zoneLabel(i-1), formatPercent(s.Zones[i-1].Lower))
+		}
+	}
+
+	return nil
+}
+
+func (s Scheme) zoneLabel(i int) string {
+	return Zone{Number: i + 1, Name: s.Zones[i].Name}.Label()
+}
+
+// A fraction as a percentage, e.g. 0.57 as "57%"
+func formatPercent(fraction float64) string {
+	return strconv.FormatFloat(math.Round(fraction*10000)/100, 'f', -1, 64) + "%"
+}
+
+// Calculate training zones from LTHR using a scheme's percentages
+func CalculateZonesWithScheme(lthr int, scheme Scheme) HeartRateZones {
+	return HeartRateZones{
+		LTHR:   lthr,
+		Scheme: scheme.Name,
+		Zones:  calculateSchemeZones(scheme.Zones, 0, lthr, 0),
+	}
+}
+
+// Zones starting at base plus their fraction of span, the top one ending at
+// top, or open-ended when top is 0
+func calculateSchemeZones(schemeZones []SchemeZone, base, span, top int) []Zone {
+	lower := func(i int) int {
+		return base + calculateZoneBoundary(span, schemeZones[i].Lower)
+	}
+
+	zones := make([]Zone, len(schemeZones))
+	for i, schemeZone := range schemeZones {
+		upper := top
+		if i+1 < len(schemeZones) {
+			upper = lower(i+1) - 1
+		}
+
+		zones[i] = Zone{Number: i + 1, Name: schemeZone.Name, Min: lower(i), Max: upper}
+	}
+
+	return zones
+}
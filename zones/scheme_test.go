@@ -0,0 +1,106 @@
+package zones
+
+import (
+	"slices"
+	"testing"
+	"zone-finder/types"
+)
+
+func TestCalculateZonesWithScheme(t *testing.T) {
+	tests := []struct {
+		name   string
+		lthr   int
+		scheme string
+		sport  types.Sport
+		zones  []Zone
+	}{
+		{
+			name:   "friel run",
+			lthr:   170,
+			scheme: "friel-run",
+			zones: []Zone{
+				{Number: 1, Name: "1", Min: 0, Max: 144},
+				{Number: 2, Name: "2", Min: 145, Max: 152},
+				{Number: 3, Name: "3", Min: 153, Max: 161},
+				{Number: 4, Name: "4", Min: 162, Max: 169},
+				{Number: 5, Name: "5a", Min: 170, Max: 174},
+				{Number: 6, Name: "5b", Min: 175, Max: 181},
+				{Number: 7, Name: "5c", Min: 182},
+			},
+		},
+		{
+			name:   "friel bike",
+			lthr:   160,
+			scheme: "friel-bike",
+			zones: []Zone{
+				{Number: 1, Name: "1", Min: 0, Max: 129},
+				{Number: 2, Name: "2", Min: 130, Max: 143},
+				{Number: 3, Name: "3", Min: 144, Max: 149},
+				{Number: 4, Name: "4", Min: 150, Max: 159},
+				{Number: 5, Name: "5a", Min: 160, Max: 164},
+				{Number: 6, Name: "5b", Min: 165, Max: 170},
+				{Number: 7, Name: "5c", Min: 171},
+			},
+		},
+		{
+			name:   "friel picks bike zones for cycling",
+			lthr:   160,
+			scheme: "friel",
+			sport:  types.SportCycling,
+			zones:  CalculateZonesWithScheme(160, frielBike).Zones,
+		},
+		{
+			name:   "friel picks run zones for other sports",
+			lthr:   160,
+			scheme: "friel",
+			sport:  types.SportOther,
+			zones:  CalculateZonesWithScheme(160, frielRun).Zones,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme, err := LookupScheme(tt.scheme, tt.sport)
+			if err != nil {
+				t.Fatalf("LookupScheme() error = %v", err)
+			}
+
+			result := CalculateZonesWithScheme(tt.lthr, scheme)
+
+			if result.LTHR != tt.lthr || result.Scheme != scheme.Name {
+				t.Errorf("LTHR = %d, Scheme = %q, want %d and %q", result.LTHR, result.Scheme, tt.lthr, scheme.Name)
+			}
+
+			if !slices.Equal(result.Zones, tt.zones) {
+				t.Errorf("Zones = %+v, want %+v", result.Zones, tt.zones)
+			}
+		})
+	}
+
+	if _, err := LookupScheme("coggan", types.SportCycling); err == nil {
+		t.Error("LookupScheme() expected error for unknown scheme")
+	}
+}
+
+func TestCalculateZonesWithOptions_Scheme(t *testing.T) {
+	scheme, _ := LookupScheme("friel", types.SportCycling)
+	result := CalculateZonesWithOptions(160, Options{Scheme: &scheme, Sport: types.SportCycling})
+
+	if len(result.Zones) != 7 || result.Sport != types.SportCycling {
+		t.Errorf("CalculateZonesWithOptions() = %+v, want 7 cycling zones", result)
+	}
+
+	if got := CalculateZonesWithOptions(160, Options{}); !slices.Equal(got.Zones, CalculateZones(160).Zones) {
+		t.Errorf("CalculateZonesWithOptions() without a scheme = %+v, want the default zones", got.Zones)
+	}
+}
+
+func TestZone_Label(t *testing.T) {
+	if got := (Zone{Number: 6, Name: "5b"}).Label(); got != "5b" {
+		t.Errorf("Label() = %q, want 5b", got)
+	}
+
+	if got := (Zone{Number: 3}).Label(); got != "3" {
+		t.Errorf("Label() = %q, want 3", got)
+	}
+}
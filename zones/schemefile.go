@@ -0,0 +1,125 @@
+package zones
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// A file of user-defined zone schemes, e.g.
+//
+//	{"schemes": [{
+//	  "name": "coach",
+//	  "anchor": "lthr",
+//	  "zones": [
+//	    {"label": "recovery", "min": 0, "max": 82},
+//	    {"label": "endurance", "min": 82, "max": 89},
+//	    {"label": "tempo", "min": 89, "max": 94},
+//	    {"label": "threshold", "min": 94, "max": 100},
+//	    {"label": "vo2max", "min": 100}
+//	  ]
+//	}]}
+//
+// Bounds are percentages of the anchor: "lthr" (the default), "max-hr" or
+// "hrr". Each zone ends where the next one starts, and the last one runs to
+// max HR
+type schemeFile struct {
+	Schemes []schemeConfig `json:"schemes"`
+}
+
+type schemeConfig struct {
+	Name   string       `json:"name"`
+	Anchor string       `json:"anchor"`
+	Zones  []zoneConfig `json:"zones"`
+}
+
+type zoneConfig struct {
+	Label string   `json:"label"`
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max"`
+}
+
+// Read user-defined zone schemes from JSON, checking each zone ends where the
+// next one starts
+func ReadSchemes(r io.Reader) ([]Scheme, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	var file schemeFile
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to decode zone schemes: %w", err)
+	}
+
+	schemes := make([]Scheme, len(file.Schemes))
+	names := make(map[string]bool)
+	for i, config := range file.Schemes {
+		scheme, err := config.scheme()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := LookupScheme(scheme.Name, ""); err == nil {
+			return nil, fmt.Errorf("zone scheme %q is built in", scheme.Name)
+		}
+		if names[scheme.Name] {
+			return nil, fmt.Errorf("zone scheme %q is defined more than once", scheme.Name)
+		}
+		names[scheme.Name] = true
+
+		schemes[i] = scheme
+	}
+
+	return schemes, nil
+}
+
+// Read user-defined zone schemes from a JSON file
+func LoadSchemes(path string) ([]Scheme, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadSchemes(f)
+}
+
+func (c schemeConfig) scheme() (Scheme, error) {
+	scheme := Scheme{Name: c.Name, Zones: make([]SchemeZone, len(c.Zones))}
+
+	if c.Anchor != "" {
+		var err error
+		if scheme.Anchor, err = ParseMethod(c.Anchor); err != nil {
+			return Scheme{}, fmt.Errorf("zone scheme %q: %w", c.Name, err)
+		}
+	}
+
+	for i, zone := range c.Zones {
+		scheme.Zones[i] = SchemeZone{Name: zone.Label, Lower: zone.Min / 100}
+	}
+
+	if err := scheme.Validate(); err != nil {
+		return Scheme{}, err
+	}
+
+	for i, zone := range c.Zones {
+		last := i == len(c.Zones)-1
+		switch {
+		case last && zone.Max != nil:
+			return Scheme{}, fmt.Errorf("zone %s of scheme %q can't have a max: the last zone runs to max HR", scheme.zoneLabel(i), c.Name)
+		case last:
+		case zone.Max == nil:
+			return Scheme{}, fmt.Errorf("zone %s of scheme %q has no max", scheme.zoneLabel(i), c.Name)
+		case *zone.Max <= zone.Min:
+			return Scheme{}, fmt.Errorf("zone %s of scheme %q ends at %v%%, before it starts at %v%%", scheme.zoneLabel(i), c.Name, *zone.Max, zone.Min)
+		case *zone.Max < c.Zones[i+1].Min:
+			return Scheme{}, fmt.Errorf("gap in scheme %q between zone %s, which ends at %v%%, and zone %s, which starts at %v%%",
+				c.Name, scheme.zoneLabel(i), *zone.Max, scheme.zoneLabel(i+1), c.Zones[i+1].Min)
+		case *zone.Max > c.Zones[i+1].Min:
+			return Scheme{}, fmt.Errorf("zones %s and %s of scheme %q overlap: %s ends at %v%% but %s starts at %v%%",
+				scheme.zoneLabel(i), scheme.zoneLabel(i+1), c.Name, scheme.zoneLabel(i), *zone.Max, scheme.zoneLabel(i+1), c.Zones[i+1].Min)
+		}
+	}
+
+	return scheme, nil
+}
@@ -0,0 +1,158 @@
+package zones
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadSchemes(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    []Scheme
+		wantErr string
+	}{
+		{
+			name: "lthr and hrr schemes",
+			json: `{"schemes": [
+				{"name": "coach", "zones": [
+					{"label": "easy", "min": 0, "max": 85},
+					{"label": "steady", "min": 85, "max": 95},
+					{"label": "hard", "min": 95}
+				]},
+				{"name": "karvonen-3", "anchor": "hrr", "zones": [
+					{"min": 60, "max": 70},
+					{"min": 70, "max": 80},
+					{"min": 80}
+				]}
+			]}`,
+			want: []Scheme{
+				{Name: "coach", Zones: []SchemeZone{{Name: "easy", Lower: 0}, {Name: "steady", Lower: 0.85}, {Name: "hard", Lower: 0.95}}},
+				{Name: "karvonen-3", Anchor: MethodHeartRateReserve, Zones: []SchemeZone{{Lower: 0.60}, {Lower: 0.70}, {Lower: 0.80}}},
+			},
+		},
+		{
+			name:    "gap between zones",
+			json:    `{"schemes": [{"name": "coach", "zones": [{"min": 0, "max": 80}, {"min": 85}]}]}`,
+			wantErr: "gap in scheme \"coach\" between zone 1, which ends at 80%, and zone 2, which starts at 85%",
+		},
+		{
+			name:    "overlapping zones",
+			json:    `{"schemes": [{"name": "coach", "zones": [{"label": "easy", "min": 0, "max": 90}, {"label": "hard", "min": 85}]}]}`,
+			wantErr: "zones easy and hard of scheme \"coach\" overlap",
+		},
+		{
+			name:    "zones out of order",
+			json:    `{"schemes": [{"name": "coach", "zones": [{"min": 90, "max": 100}, {"min": 80}]}]}`,
+			wantErr: "zone 2 of scheme \"coach\" starts at 80%, overlapping zone 1",
+		},
+		{
+			name:    "zone without a max",
+			json:    `{"schemes": [{"name": "coach", "zones": [{"min": 0}, {"min": 85}]}]}`,
+			wantErr: "zone 1 of scheme \"coach\" has no max",
+		},
+		{
+			name:    "last zone with a max",
+			json:    `{"schemes": [{"name": "coach", "zones": [{"min": 0, "max": 85}, {"min": 85, "max": 110}]}]}`,
+			wantErr: "the last zone runs to max HR",
+		},
+		{
+			name:    "negative bound",
+			json:    `{"schemes": [{"name": "coach", "zones": [{"min": -10}]}]}`,
+			wantErr: "starts below 0%",
+		},
+		{
+			name:    "unknown anchor",
+			json:    `{"schemes": [{"name": "coach", "anchor": "vo2", "zones": [{"min": 0}]}]}`,
+			wantErr: "unknown zone method \"vo2\"",
+		},
+		{
+			name:    "no zones",
+			json:    `{"schemes": [{"name": "coach"}]}`,
+			wantErr: "has no zones",
+		},
+		{
+			name:    "no name",
+			json:    `{"schemes": [{"zones": [{"min": 0}]}]}`,
+			wantErr: "zone scheme has no name",
+		},
+		{
+			name:    "built-in name",
+			json:    `{"schemes": [{"name": "friel", "zones": [{"min": 0}]}]}`,
+			wantErr: "zone scheme \"friel\" is built in",
+		},
+		{
+			name:    "duplicate names",
+			json:    `{"schemes": [{"name": "coach", "zones": [{"min": 0}]}, {"name": "coach", "zones": [{"min": 0}]}]}`,
+			wantErr: "defined more than once",
+		},
+		{
+			name:    "misspelt field",
+			json:    `{"schemes": [{"name": "coach", "zones": [{"lower": 0}]}]}`,
+			wantErr: "unknown field \"lower\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schemes, err := ReadSchemes(strings.NewReader(tt.json))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ReadSchemes() error = %v, want substring %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ReadSchemes() error = %v", err)
+			}
+
+			if !slices.EqualFunc(schemes, tt.want, func(a, b Scheme) bool {
+				return a.Name == b.Name && a.Anchor == b.Anchor && slices.Equal(a.Zones, b.Zones)
+			}) {
+				t.Errorf("ReadSchemes() = %+v, want %+v", schemes, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateMaxHRZonesWithScheme(t *testing.T) {
+	scheme := Scheme{Name: "karvonen-3", Anchor: MethodHeartRateReserve, Zones: []SchemeZone{{Lower: 0.60}, {Lower: 0.70}, {Lower: 0.80}}}
+
+	result := CalculateMaxHRZonesWithScheme(190, 50, scheme)
+
+	want := []Zone{
+		{Number: 1, Min: 134, Max: 147},
+		{Number: 2, Min: 148, Max: 161},
+		{Number: 3, Min: 162, Max: 190},
+	}
+	if !slices.Equal(result.Zones, want) {
+		t.Errorf("Zones = %+v, want %+v", result.Zones, want)
+	}
+
+	if result.Method != MethodHeartRateReserve || result.RestingHR != 50 || result.MaxHR != 190 || result.Scheme != "karvonen-3" {
+		t.Errorf("CalculateMaxHRZonesWithScheme() = %+v, want hrr zones from 50-190 bpm named after the scheme", result)
+	}
+}
+
+func TestCalculateZonesFromHRDataWithOptions_SchemeAnchor(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	scheme := Scheme{Name: "max-3", Anchor: MethodMaxHR, Zones: []SchemeZone{{Lower: 0.60}, {Lower: 0.75}, {Lower: 0.90}}}
+
+	// the scheme's anchor wins over the default LTHR method
+	result, err := CalculateZonesFromHRDataWithOptions(createTimeTrial(baseTime), Options{Scheme: &scheme, MaxHR: 200})
+	if err != nil {
+		t.Fatalf("CalculateZonesFromHRDataWithOptions() error = %v", err)
+	}
+
+	want := []Zone{
+		{Number: 1, Min: 120, Max: 149},
+		{Number: 2, Min: 150, Max: 179},
+		{Number: 3, Min: 180, Max: 200},
+	}
+	if result.Method != MethodMaxHR || !slices.Equal(result.Zones, want) {
+		t.Errorf("CalculateZonesFromHRDataWithOptions() = %+v, want max-hr zones %+v", result, want)
+	}
+}
@@ -0,0 +1,84 @@
+package zones
+
+import (
+	"time"
+	"zone-finder/types"
+)
+
+// How a workout's time divides between zones
+type TimeInZones struct {
+	Zones []ZoneTime
+	// Time below the bottom zone, e.g. under 50% of max HR when warming up
+	Below time.Duration
+	// Time covered by heart rate data: the sum of the above, excluding gaps
+	Total time.Duration
+}
+
+type ZoneTime struct {
+	Zone     Zone
+	Duration time.Duration
+}
+
+// Percentage of the workout's covered time that a duration is, e.g. the
+// Duration of a ZoneTime
+func (t TimeInZones) Percent(d time.Duration) float64 {
+	if t.Total == 0 {
+		return 0
+	}
+
+	return float64(d) / float64(t.Total) * 100
+}
+
+// Calculate the time spent in each zone. Each sample counts for the interval
+// until the next one, so sparse and 1 Hz recordings are treated alike. A
+// sample followed by a gap counts only for the gap threshold, rather than the
+// gap's length. Heart rates above the top zone count towards it
+func CalculateTimeInZones(dataPoints []types.HRDataPoint, zones HeartRateZones, gaps GapPolicy) TimeInZones {
+	gaps = gaps.WithDefaults()
+
+	distribution := TimeInZones{Zones: make([]ZoneTime, len(zones.Zones))}
+	for i, zone := range zones.Zones {
+		distribution.Zones[i].Zone = zone
+	}
+	if len(zones.Zones) == 0 {
+		return distribution
+	}
+
+	sortByTimestamp(dataPoints)
+
+	for i := 0; i+1 < len(dataPoints); i++ {
+		interval := min(dataPoints[i+1].Timestamp.Sub(dataPoints[i].Timestamp), gaps.Threshold)
+
+		if index := zoneIndex(zones.Zones, dataPoints[i].HeartRate); index < 0 {
+			distribution.Below += interval
+		} else {
+			distribution.Zones[index].Duration += interval
+		}
+		distribution.Total += interval
+	}
+
+	return distribution
+}
+
+// The zone a heart rate is in, the top one when it's above them all. false
+// when it's below them all
+func (z HeartRateZones) ZoneFor(heartRate int) (Zone, bool) {
+	index := zoneIndex(z.Zones, heartRate)
+	if index < 0 {
+		return Zone{}, false
+	}
+
+	return z.Zones[index], true
+}
+
+// Index of the zone a heart rate is in, the top one when it's above them all,
+// or -1 when it's below them all
+func zoneIndex(zones []Zone, heartRate int) int {
+	for i := len(zones) - 1; i >= 0; i-- {
+		if heartRate >= zones[i].Min {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,141 @@
+package zones
+
+import (
+	"slices"
+	"testing"
+	"time"
+	"zone-finder/types"
+)
+
+func TestCalculateTimeInZones(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	lthrZones := CalculateZones(170) // zone 1 below 136, zone 5 from 171
+
+	tests := []struct {
+		name      string
+		data      []types.HRDataPoint
+		zones     HeartRateZones
+		gaps      GapPolicy
+		want      []time.Duration
+		wantBelow time.Duration
+		wantTotal time.Duration
+	}{
+		{
+			name: "1 Hz recording",
+			data: append(
+				createConstantHR(baseTime, 120, 10*60),
+				createConstantHR(baseTime.Add(10*time.Minute), 172, 5*60+1)...,
+			),
+			zones:     lthrZones,
+			want:      []time.Duration{10 * time.Minute, 0, 0, 0, 5 * time.Minute},
+			wantTotal: 15 * time.Minute,
+		},
+		{
+			name:      "sparse recording counts each sample until the next",
+			data:      createSmartRecording(baseTime),
+			zones:     lthrZones,
+			want:      []time.Duration{0, 0, 22*time.Minute + 50*time.Second, 0, 2 * time.Minute},
+			wantTotal: 24*time.Minute + 50*time.Second, // the last sample has no interval
+		},
+		{
+			name: "sample before a gap counts for the gap threshold",
+			data: []types.HRDataPoint{
+				{Timestamp: baseTime, HeartRate: 150},
+				{Timestamp: baseTime.Add(5 * time.Second), HeartRate: 165},
+				{Timestamp: baseTime.Add(10 * time.Minute), HeartRate: 165},
+				{Timestamp: baseTime.Add(10*time.Minute + 5*time.Second), HeartRate: 165},
+			},
+			zones:     lthrZones,
+			want:      []time.Duration{0, 5 * time.Second, 0, 35 * time.Second, 0},
+			wantTotal: 40 * time.Second,
+		},
+		{
+			name: "longer gap threshold",
+			data: []types.HRDataPoint{
+				{Timestamp: baseTime, HeartRate: 150},
+				{Timestamp: baseTime.Add(5 * time.Second), HeartRate: 165},
+				{Timestamp: baseTime.Add(10 * time.Minute), HeartRate: 165},
+				{Timestamp: baseTime.Add(10*time.Minute + 5*time.Second), HeartRate: 165},
+			},
+			zones:     lthrZones,
+			gaps:      GapPolicy{Threshold: time.Minute},
+			want:      []time.Duration{0, 5 * time.Second, 0, time.Minute + 5*time.Second, 0},
+			wantTotal: time.Minute + 10*time.Second,
+		},
+		{
+			name:      "sampling every 15 seconds isn't a gap",
+			data:      createSparseHR(baseTime, 165, time.Hour, 15*time.Second),
+			zones:     lthrZones,
+			want:      []time.Duration{0, 0, 0, time.Hour - 15*time.Second, 0},
+			wantTotal: time.Hour - 15*time.Second,
+		},
+		{
+			name:      "heart rates below the bottom zone",
+			data:      createHRData(baseTime, []int{80, 80, 80, 100, 100, 100}),
+			zones:     CalculateMaxHRZones(190), // zone 1 from 95
+			want:      []time.Duration{2 * time.Second, 0, 0, 0, 0},
+			wantBelow: 3 * time.Second,
+			wantTotal: 5 * time.Second,
+		},
+		{
+			name:      "heart rates above the top zone count towards it",
+			data:      createHRData(baseTime, []int{195, 195, 195}),
+			zones:     CalculateMaxHRZones(190),
+			want:      []time.Duration{0, 0, 0, 0, 2 * time.Second},
+			wantTotal: 2 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CalculateTimeInZones(tt.data, tt.zones, tt.gaps)
+
+			got := make([]time.Duration, len(result.Zones))
+			for i, zoneTime := range result.Zones {
+				got[i] = zoneTime.Duration
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("time in zones = %v, want %v", got, tt.want)
+			}
+
+			if result.Below != tt.wantBelow || result.Total != tt.wantTotal {
+				t.Errorf("Below = %v, Total = %v, want %v and %v", result.Below, result.Total, tt.wantBelow, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestTimeInZones_Percent(t *testing.T) {
+	distribution := TimeInZones{Total: 40 * time.Minute}
+
+	if got := distribution.Percent(10 * time.Minute); got != 25 {
+		t.Errorf("Percent() = %v, want 25", got)
+	}
+
+	if got := (TimeInZones{}).Percent(time.Minute); got != 0 {
+		t.Errorf("Percent() = %v without any time, want 0", got)
+	}
+}
+
+func TestHeartRateZones_ZoneFor(t *testing.T) {
+	maxHRZones := CalculateMaxHRZones(190) // zone 1 from 95, zone 5 from 171
+
+	tests := []struct {
+		heartRate int
+		want      int
+		wantOK    bool
+	}{
+		{heartRate: 80, wantOK: false},
+		{heartRate: 95, want: 1, wantOK: true},
+		{heartRate: 170, want: 4, wantOK: true},
+		{heartRate: 171, want: 5, wantOK: true},
+		{heartRate: 200, want: 5, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		zone, ok := maxHRZones.ZoneFor(tt.heartRate)
+		if ok != tt.wantOK || zone.Number != tt.want {
+			t.Errorf("ZoneFor(%d) = zone %d, %v, want zone %d, %v", tt.heartRate, zone.Number, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package zones
+
+import (
+	"time"
+	"zone-finder/types"
+)
+
+// The part of a workout LTHR was calculated from
+type Window struct {
+	Start   time.Time
+	End     time.Time
+	Offset  time.Duration // from the start of the workout
+	Samples int
+	MinHR   int
+	MaxHR   int
+	AvgHR   float64 // as averaged, before rounding or protocol adjustments
+}
+
+func (w Window) Duration() time.Duration {
+	return w.End.Sub(w.Start)
+}
+
+// Describe the data points LTHR was calculated from, sorted by timestamp,
+// relative to the workout starting at workoutStart
+func NewWindow(dataPoints []types.HRDataPoint, workoutStart time.Time, averaging Averaging) Window {
+	window := Window{
+		Start:   dataPoints[0].Timestamp,
+		End:     dataPoints[len(dataPoints)-1].Timestamp,
+		Offset:  dataPoints[0].Timestamp.Sub(workoutStart),
+		Samples: len(dataPoints),
+		MinHR:   dataPoints[0].HeartRate,
+		MaxHR:   dataPoints[0].HeartRate,
+		AvgHR:   averageHeartRate(dataPoints, averaging),
+	}
+
+	for _, dp := range dataPoints {
+		window.MinHR = min(window.MinHR, dp.HeartRate)
+		window.MaxHR = max(window.MaxHR, dp.HeartRate)
+	}
+
+	return window
+}
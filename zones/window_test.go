@@ -0,0 +1,113 @@
+package zones
+
+import (
+	"testing"
+	"time"
+	"zone-finder/types"
+)
+
+func TestFindLTHRWithWindow(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	var data []types.HRDataPoint
+	data = append(data, createConstantHR(baseTime, 100, 10*60)...)
+	data = append(data, createConstantHR(baseTime.Add(10*time.Minute), 165, 25*60)...)
+
+	wantWindow := Window{
+		Start:   baseTime.Add(10 * time.Minute),
+		End:     baseTime.Add(30 * time.Minute),
+		Offset:  10 * time.Minute,
+		Samples: 20*60 + 1,
+		MinHR:   165,
+		MaxHR:   165,
+		AvgHR:   165,
+	}
+
+	tests := []struct {
+		name     string
+		protocol Protocol
+		wantLTHR int
+	}{
+		{name: "best window", protocol: ProtocolBestWindow, wantLTHR: 165},
+		{name: "95 percent reports the unadjusted average", protocol: ProtocolTwentyMinute, wantLTHR: 157},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lthr, window, err := FindLTHRWithWindow(data, Options{Protocol: tt.protocol})
+			if err != nil {
+				t.Fatalf("FindLTHRWithWindow() error = %v", err)
+			}
+
+			if lthr != tt.wantLTHR {
+				t.Errorf("LTHR = %d, want %d", lthr, tt.wantLTHR)
+			}
+
+			if window != wantWindow {
+				t.Errorf("Window = %+v, want %+v", window, wantWindow)
+			}
+		})
+	}
+}
+
+func TestFindLTHRWithWindow_RoundsAverage(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	data := createConstantHR(baseTime, 166, 20*60+1)
+	for i := 0; i < len(data); i += 4 {
+		data[i].HeartRate = 165
+	}
+
+	lthr, window, err := FindLTHRWithWindow(data, Options{})
+	if err != nil {
+		t.Fatalf("FindLTHRWithWindow() error = %v", err)
+	}
+
+	if window.AvgHR <= 165.5 || window.AvgHR >= 166 {
+		t.Fatalf("AvgHR = %v, want between 165.5 and 166", window.AvgHR)
+	}
+
+	if lthr != 166 {
+		t.Errorf("LTHR = %d, want the average %v rounded to 166", lthr, window.AvgHR)
+	}
+}
+
+func TestNewWindow(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	data := createHRData(baseTime.Add(5*time.Minute), []int{150, 171, 168, 149, 152})
+
+	window := NewWindow(data, baseTime, AveragingSampleMean)
+
+	if window.MinHR != 149 || window.MaxHR != 171 {
+		t.Errorf("MinHR, MaxHR = %d, %d, want 149, 171", window.MinHR, window.MaxHR)
+	}
+
+	if window.AvgHR != 158 {
+		t.Errorf("AvgHR = %v, want 158", window.AvgHR)
+	}
+
+	if window.Offset != 5*time.Minute || window.Duration() != 4*time.Second || window.Samples != 5 {
+		t.Errorf("Window = %+v, want 5 samples over 4s starting 5m in", window)
+	}
+}
+
+func TestCalculateZonesFromHRData_Window(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	zones, err := CalculateZonesFromHRData(createTimeTrial(baseTime))
+	if err != nil {
+		t.Fatalf("CalculateZonesFromHRData() error = %v", err)
+	}
+
+	if zones.Window == nil {
+		t.Fatal("Expected the window LTHR was calculated from")
+	}
+
+	if zones.Window.Duration() != 20*time.Minute {
+		t.Errorf("Window duration = %v, want 20m0s", zones.Window.Duration())
+	}
+
+	if CalculateZones(zones.LTHR).Window != nil {
+		t.Error("Expected no window for zones calculated from a given LTHR")
+	}
+}
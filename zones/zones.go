@@ -1,77 +1,123 @@
 package zones
 
 import (
-	"errors"
+	"fmt"
 	"math"
-	"sort"
+	"slices"
+	"strconv"
 	"time"
 	"zone-finder/types"
 )
 
 type HeartRateZones struct {
-	LTHR  int
-	Zones [5]Zone
+	Method Method
+	LTHR   int // 0 when zones aren't based on LTHR
+	MaxHR  int // 0 when not known
+	// MaxHRUnknown when MaxHR isn't known
+	MaxHRSource MaxHRSource
+	RestingHR   int // 0 when zones aren't based on heart rate reserve
+	// RestingHRUnknown when RestingHR isn't known
+	RestingHRSource RestingHRSource
+	Sport           types.Sport // empty when zones aren't sport-specific
+	Scheme          string      // empty for the default five zones
+	Zones           []Zone
+	// Zones starting above MaxHR, left out of Zones
+	AboveMaxHR []Zone
+	// nil when LTHR wasn't found from a workout
+	Window *Window
 }
 
 type Zone struct {
 	Number int
+	Name   string // as labelled by a scheme, e.g. "5a"; empty when zones are only numbered
 	Min    int
-	Max    int
+	Max    int // 0 for an open-ended top zone, when max HR isn't known
+}
+
+// How the zone is labelled, e.g. "3" or "5a"
+func (z Zone) Label() string {
+	if z.Name != "" {
+		return z.Name
+	}
+
+	return strconv.Itoa(z.Number)
 }
 
 const (
-	windowDuration                = 20 * time.Minute
-	minAcceptableDuration         = (windowDuration - 2*time.Second)
-	maxHeartRate                  = 220
-	zone2Lower            float64 = 0.80
-	zone2Upper            float64 = 0.88
-	zone3Upper            float64 = 0.94
+	windowDuration          = 20 * time.Minute
+	windowTolerance         = 2 * time.Second
+	zone2Lower      float64 = 0.80
+	zone2Upper      float64 = 0.88
+	zone3Upper      float64 = 0.94
 )
 
-// Calculate training zones from HR data points using LTHR method
-func CalculateZonesFromHRData(dataPoints []types.HRDataPoint) (HeartRateZones, error) {
-	sortByTimestamp(dataPoints)
+// Upper bounds of zones 1-3 as fractions of LTHR
+type zoneBoundaries struct {
+	zone2Lower float64
+	zone2Upper float64
+	zone3Upper float64
+}
 
-	bestAvailableWindow, err := FindBestWindow(dataPoints)
-	if err != nil {
-		return HeartRateZones{}, err
-	}
+var defaultBoundaries = zoneBoundaries{zone2Lower, zone2Upper, zone3Upper}
 
-	lactateThreshold := CalculateLTHR(bestAvailableWindow)
+// Sports whose zones differ from the running-based defaults. Cycling uses
+// Joe Friel's bike percentages
+var sportBoundaries = map[types.Sport]zoneBoundaries{
+	types.SportCycling: {zone2Lower: 0.81, zone2Upper: 0.89, zone3Upper: 0.93},
+}
 
-	zones := CalculateZones(lactateThreshold)
+// Calculate training zones from HR data points using LTHR method
+func CalculateZonesFromHRData(dataPoints []types.HRDataPoint) (HeartRateZones, error) {
+	return CalculateZonesFromHRDataWithOptions(dataPoints, Options{})
+}
 
-	return zones, nil
+// Find the LTHR as the average of the 20-minute window with the highest
+// average heart rate
+func FindLTHR(dataPoints []types.HRDataPoint) (int, error) {
+	return FindLTHRWithProtocol(dataPoints, ProtocolBestWindow)
 }
 
 func sortByTimestamp(dataPoints []types.HRDataPoint) {
-	sort.Slice(dataPoints, func(i, j int) bool { return dataPoints[i].Timestamp.Before(dataPoints[j].Timestamp) })
+	slices.SortFunc(dataPoints, func(a, b types.HRDataPoint) int { return a.Timestamp.Compare(b.Timestamp) })
 }
 
-// Calculate the Lactate Threshold Heart Rate from a set of HR data points
+// Calculate the Lactate Threshold Heart Rate from a set of HR data points,
+// their mean heart rate rounded as FindLTHR does. Returns 0 without any
 func CalculateLTHR(dataPoints []types.HRDataPoint) int {
-	sum := 0
-	for _, dp := range dataPoints {
-		sum += dp.HeartRate
+	return int(math.Round(averageHeartRate(dataPoints, AveragingSampleMean)))
+}
+
+func CalculateZones(lthr int) HeartRateZones {
+	return calculateZones(lthr, defaultBoundaries)
+}
+
+// Calculate training zones using the sport's default percentages of LTHR
+func CalculateZonesForSport(lthr int, sport types.Sport) HeartRateZones {
+	boundaries, ok := sportBoundaries[sport]
+	if !ok {
+		boundaries = defaultBoundaries
 	}
 
-	return sum / len(dataPoints)
+	zones := calculateZones(lthr, boundaries)
+	zones.Sport = sport
+
+	return zones
 }
 
-func CalculateZones(lthr int) HeartRateZones {
-	z2Lower := calculateZoneBoundary(lthr, zone2Lower)
-	z2Upper := calculateZoneBoundary(lthr, zone2Upper)
-	z3Upper := calculateZoneBoundary(lthr, zone3Upper)
+func calculateZones(lthr int, boundaries zoneBoundaries) HeartRateZones {
+	z2Lower := calculateZoneBoundary(lthr, boundaries.zone2Lower)
+	z2Upper := calculateZoneBoundary(lthr, boundaries.zone2Upper)
+	z3Upper := calculateZoneBoundary(lthr, boundaries.zone3Upper)
 	z4Upper := lthr
 
 	return HeartRateZones{
 		LTHR: lthr,
-		Zones: [5]Zone{
+		Zones: []Zone{
 			{Number: 1, Min: 0, Max: z2Lower - 1},
 			{Number: 2, Min: z2Lower, Max: z2Upper},
 			{Number: 3, Min: z2Upper + 1, Max: z3Upper},
 			{Number: 4, Min: z3Upper + 1, Max: z4Upper},
-			{Number: 5, Min: z4Upper + 1, Max: maxHeartRate},
+			{Number: 5, Min: z4Upper + 1},
 		},
 	}
 }
@@ -82,58 +128,102 @@ func calculateZoneBoundary(lthr int, percentage float64) int {
 
 // Finds the 20-minute window with the highest average heart rate
 func FindBestWindow(dataPoints []types.HRDataPoint) ([]types.HRDataPoint, error) {
-	sortByTimestamp(dataPoints)
+	return findBestWindow(dataPoints, windowDuration, DefaultOptions())
+}
 
-	var bestWindow []types.HRDataPoint
-	var bestAvg float64
+// Finds the window of the given duration with the highest average heart rate
+func findBestWindow(dataPoints []types.HRDataPoint, duration time.Duration, opts Options) ([]types.HRDataPoint, error) {
+	start, end, err := findBestWindowIndices(dataPoints, duration, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataPoints[start:end], nil
+}
+
+// Finds the 20-minute window with the highest average heart rate, returning
+// it as the half-open range [start, end) of dataPoints after sorting them by
+// timestamp
+func FindBestWindowIndices(dataPoints []types.HRDataPoint) (start int, end int, err error) {
+	return findBestWindowIndices(dataPoints, windowDuration, DefaultOptions())
+}
+
+// Slides a window across the workout keeping running sums of weighted heart
+// rates and gap time, so each data point is added and removed exactly once.
+// Windows with too little heart rate coverage are skipped. Averaging, gap
+// policy and tolerance are taken from opts, which must have its defaults
+// applied
+func findBestWindowIndices(dataPoints []types.HRDataPoint, duration time.Duration, opts Options) (int, int, error) {
+	sortByTimestamp(dataPoints)
+	minAcceptableDuration := duration - opts.Tolerance
+	averaging, gaps := opts.Averaging, opts.Gaps
 
 	if len(dataPoints) == 0 {
-		return nil, errors.New("no HR data provided")
+		return 0, 0, ErrNoHRData
 	}
 
 	workoutDuration := dataPoints[len(dataPoints)-1].Timestamp.Sub(dataPoints[0].Timestamp)
-	if workoutDuration < windowDuration {
-		return nil, errors.New("workout too short: need at least 20 minutes")
+	if workoutDuration < duration {
+		return 0, 0, &TooShortError{Have: workoutDuration, Need: duration}
 	}
 
-	for i := 0; i < len(dataPoints); i++ {
-		startTime := dataPoints[i].Timestamp
-		endTime := startTime.Add(windowDuration)
-
-		var window []types.HRDataPoint
-		for j := i; j < len(dataPoints); j++ {
-			if !dataPoints[j].Timestamp.After(endTime) {
-				window = append(window, dataPoints[j])
-			} else {
-				break
+	bestStart, bestEnd := -1, -1
+	var bestAvg float64
+	refused := false
+
+	// only samples in [start, end-lag) count towards the window's average
+	lag := averaging.lag()
+	var weighted, total int64
+	var gapTime time.Duration
+	end := 0
+	for start := range dataPoints {
+		endTime := dataPoints[start].Timestamp.Add(duration)
+
+		for end < len(dataPoints) && !dataPoints[end].Timestamp.After(endTime) {
+			if end > start {
+				gapTime += gaps.gapAfter(dataPoints, end-1)
 			}
-		}
 
-		if len(window) == 0 {
-			continue
+			end++
+			if i := end - 1 - lag; i >= start {
+				weight := averaging.weight(dataPoints, i)
+				weighted += int64(dataPoints[i].HeartRate) * weight
+				total += weight
+			}
 		}
 
-		actualDuration := window[len(window)-1].Timestamp.Sub(window[0].Timestamp)
-		if actualDuration < minAcceptableDuration {
-			// stop iterating when there's no longer 20 minutes of data left
+		actualDuration := dataPoints[end-1].Timestamp.Sub(dataPoints[start].Timestamp)
+		if actualDuration < minAcceptableDuration && end == len(dataPoints) {
+			// stop iterating when there's no longer a full window of data left
 			break
 		}
 
-		sum := 0
-		for _, dp := range window {
-			sum += dp.HeartRate
+		// a window cut short by a gap after it isn't a full window either
+		if actualDuration >= minAcceptableDuration && total > 0 {
+			if !gaps.covered(actualDuration, gapTime) {
+				refused = true
+			} else if avg := float64(weighted) / float64(total); avg > bestAvg {
+				bestAvg = avg
+				bestStart, bestEnd = start, end
+			}
 		}
 
-		avg := float64(sum) / float64(len(window))
-		if avg > bestAvg {
-			bestAvg = avg
-			bestWindow = window
+		if start < end-lag {
+			weight := averaging.weight(dataPoints, start)
+			weighted -= int64(dataPoints[start].HeartRate) * weight
+			total -= weight
+		}
+		if start < end-1 {
+			gapTime -= gaps.gapAfter(dataPoints, start)
 		}
 	}
 
-	if bestWindow == nil {
-		return nil, errors.New("Could not find valid 20-minute window")
+	if bestStart < 0 && refused {
+		return 0, 0, &CoverageError{Window: duration, MinCoverage: gaps.MinCoverage}
+	}
+	if bestStart < 0 {
+		return 0, 0, fmt.Errorf("%w of %v", ErrNoValidWindow, duration)
 	}
 
-	return bestWindow, nil
+	return bestStart, bestEnd, nil
 }
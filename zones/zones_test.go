@@ -1,6 +1,7 @@
 package zones
 
 import (
+	"slices"
 	"testing"
 	"time"
 	"zone-finder/types"
@@ -68,7 +69,7 @@ func TestCalculateLTHR(t *testing.T) {
 				}
 				return data
 			}(),
-			want: 169, // Average of 160-179
+			want: 170, // Average of 160-179 is 169.5
 		},
 		{
 			name: "two distinct heart rates",
@@ -81,6 +82,25 @@ func TestCalculateLTHR(t *testing.T) {
 			}(),
 			want: 170, // Average of 160 and 180
 		},
+		{
+			name: "average rounds to the nearest bpm",
+			data: func() []types.HRDataPoint {
+				// 3 in 5 at 173, the rest at 172, average 172.6
+				data := createConstantHR(baseTime, 173, 20*60)
+				for i := range data {
+					if i%5 < 2 {
+						data[i].HeartRate = 172
+					}
+				}
+				return data
+			}(),
+			want: 173,
+		},
+		{
+			name: "no data",
+			data: nil,
+			want: 0,
+		},
 	}
 
 	for _, tt := range tests {
@@ -98,28 +118,28 @@ func TestCalculateZones(t *testing.T) {
 	tests := []struct {
 		name  string
 		lthr  int
-		zones [5]Zone
+		zones []Zone
 	}{
 		{
 			name: "LTHR 172",
 			lthr: 172,
-			zones: [5]Zone{
+			zones: []Zone{
 				{Number: 1, Min: 0, Max: 137},
 				{Number: 2, Min: 138, Max: 151},
 				{Number: 3, Min: 152, Max: 162},
 				{Number: 4, Min: 163, Max: 172},
-				{Number: 5, Min: 173, Max: 220},
+				{Number: 5, Min: 173},
 			},
 		},
 		{
 			name: "LTHR 160",
 			lthr: 160,
-			zones: [5]Zone{
+			zones: []Zone{
 				{Number: 1, Min: 0, Max: 127},
 				{Number: 2, Min: 128, Max: 141},
 				{Number: 3, Min: 142, Max: 150},
 				{Number: 4, Min: 151, Max: 160},
-				{Number: 5, Min: 161, Max: 220},
+				{Number: 5, Min: 161},
 			},
 		},
 	}
@@ -142,6 +162,54 @@ func TestCalculateZones(t *testing.T) {
 	}
 }
 
+func TestCalculateZonesForSport(t *testing.T) {
+	tests := []struct {
+		name  string
+		lthr  int
+		sport types.Sport
+		zones []Zone
+	}{
+		{
+			name:  "running uses the default percentages",
+			lthr:  172,
+			sport: types.SportRunning,
+			zones: CalculateZones(172).Zones,
+		},
+		{
+			name:  "cycling",
+			lthr:  160,
+			sport: types.SportCycling,
+			zones: []Zone{
+				{Number: 1, Min: 0, Max: 129},
+				{Number: 2, Min: 130, Max: 142},
+				{Number: 3, Min: 143, Max: 149},
+				{Number: 4, Min: 150, Max: 160},
+				{Number: 5, Min: 161},
+			},
+		},
+		{
+			name:  "other sports fall back to the defaults",
+			lthr:  160,
+			sport: types.SportOther,
+			zones: CalculateZones(160).Zones,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CalculateZonesForSport(tt.lthr, tt.sport)
+
+			if result.LTHR != tt.lthr || result.Sport != tt.sport {
+				t.Errorf("LTHR = %d, Sport = %q, want %d and %q", result.LTHR, result.Sport, tt.lthr, tt.sport)
+			}
+
+			if !slices.Equal(result.Zones, tt.zones) {
+				t.Errorf("Zones = %+v, want %+v", result.Zones, tt.zones)
+			}
+		})
+	}
+}
+
 func TestCalculateZonesFromHRData(t *testing.T) {
 	baseTime := time.Date(2025, 10, 28, 18, 0, 0, 0, time.UTC)
 
@@ -281,6 +349,14 @@ func TestFindBestWindow(t *testing.T) {
 
 			lthr := CalculateLTHR(window)
 
+			zones, err := CalculateZonesFromHRData(tt.dataSetup())
+			if err != nil {
+				t.Fatalf("CalculateZonesFromHRData() error = %v", err)
+			}
+			if lthr != zones.LTHR {
+				t.Errorf("CalculateLTHR() of the best window = %d, but CalculateZonesFromHRData() found %d", lthr, zones.LTHR)
+			}
+
 			if lthr < tt.wantLTHR-2 || lthr > tt.wantLTHR+2 {
 				t.Errorf("LTHR = %d, want ~%d (±2)", lthr, tt.wantLTHR)
 			}
@@ -294,3 +370,108 @@ func TestFindBestWindow(t *testing.T) {
 		})
 	}
 }
+
+func TestFindBestWindowIndices(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	var data []types.HRDataPoint
+	data = append(data, createConstantHR(baseTime, 100, 10*60)...)
+	data = append(data, createConstantHR(baseTime.Add(10*time.Minute), 165, 25*60)...)
+
+	start, end, err := FindBestWindowIndices(data)
+	if err != nil {
+		t.Fatalf("FindBestWindowIndices() error = %v", err)
+	}
+
+	// The first window entirely within the effort, 20 minutes inclusive
+	if start != 600 || end != 600+20*60+1 {
+		t.Errorf("FindBestWindowIndices() = [%d, %d), want [600, 1801)", start, end)
+	}
+
+	window, err := FindBestWindow(data)
+	if err != nil {
+		t.Fatalf("FindBestWindow() error = %v", err)
+	}
+
+	if &window[0] != &data[start] || len(window) != end-start {
+		t.Error("FindBestWindow() should return the window as a slice of the input")
+	}
+}
+
+// Irregularly sampled workouts must give the same window as re-averaging
+// every candidate window from scratch
+func TestFindBestWindowIndices_MatchesNaiveSearch(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	var data []types.HRDataPoint
+	offset := time.Duration(0)
+	for i := 0; i < 3000; i++ {
+		offset += time.Duration(1+(i*7)%5) * time.Second
+		data = append(data, types.HRDataPoint{
+			Timestamp: baseTime.Add(offset),
+			HeartRate: 130 + (i*31)%50,
+		})
+	}
+
+	for _, averaging := range []Averaging{AveragingSampleMean, AveragingTimeWeighted} {
+		start, end, err := findBestWindowIndices(data, windowDuration, Options{Averaging: averaging}.withDefaults())
+		if err != nil {
+			t.Fatalf("findBestWindowIndices(%v) error = %v", averaging, err)
+		}
+
+		wantStart, wantEnd := naiveBestWindow(data, windowDuration, averaging)
+		if start != wantStart || end != wantEnd {
+			t.Errorf("findBestWindowIndices(%v) = [%d, %d), want [%d, %d)", averaging, start, end, wantStart, wantEnd)
+		}
+	}
+}
+
+func naiveBestWindow(data []types.HRDataPoint, duration time.Duration, averaging Averaging) (int, int) {
+	bestStart, bestEnd := -1, -1
+	var bestAvg float64
+
+	for i := range data {
+		j := i
+		for j < len(data) && !data[j].Timestamp.After(data[i].Timestamp.Add(duration)) {
+			j++
+		}
+
+		if data[j-1].Timestamp.Sub(data[i].Timestamp) < duration-windowTolerance {
+			break
+		}
+
+		if avg := averageHeartRate(data[i:j], averaging); avg > bestAvg {
+			bestAvg = avg
+			bestStart, bestEnd = i, j
+		}
+	}
+
+	return bestStart, bestEnd
+}
+
+// 1 Hz workout with a slowly varying heart rate
+func createLongWorkout(baseTime time.Time, duration time.Duration) []types.HRDataPoint {
+	seconds := int(duration.Seconds())
+	hrValues := make([]int, seconds)
+	for i := range hrValues {
+		hrValues[i] = 140 + (i/60)%30
+	}
+	return createHRData(baseTime, hrValues)
+}
+
+func BenchmarkFindBestWindow(b *testing.B) {
+	baseTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	for _, duration := range []time.Duration{time.Hour, 3 * time.Hour, 6 * time.Hour} {
+		dataPoints := createLongWorkout(baseTime, duration)
+
+		b.Run(duration.String(), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := FindBestWindow(dataPoints); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}